@@ -0,0 +1,171 @@
+package dotignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveOptions controls how WriteTar, WriteTarGz and WriteZip build an archive.
+type ArchiveOptions struct {
+	// Prefix is prepended to every entry name, for example "myapp-1.0/". A slash is added if
+	// missing.
+	Prefix string
+	// ModTime, if not zero, is recorded as the modification time of every entry instead of the
+	// file's own, which together with the fixed entry order makes the archive reproducible.
+	ModTime time.Time
+}
+
+// archiveEntry is a file, directory or symlink to be written to an archive.
+type archiveEntry struct {
+	name   string // slash-separated name inside the archive; directories end in "/"
+	path   string // path on disk
+	info   fs.FileInfo
+	target string // symlink target
+}
+
+// walkArchive visits, in lexical order, every path under root that matcher does not ignore, and
+// calls fn with the corresponding archive entry. Ignored directories are skipped entirely.
+func walkArchive(root string, matcher *PatternMatcher, opts ArchiveOptions, fn func(entry archiveEntry) error) error {
+	prefix := opts.Prefix
+	if prefix != "" && prefix[len(prefix)-1] != '/' {
+		prefix += "/"
+	}
+
+	return walkIncluded(root, matcher, func(rel string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := archiveEntry{
+			name: path.Join(prefix, rel),
+			path: filepath.Join(root, filepath.FromSlash(rel)),
+			info: info,
+		}
+		switch {
+		case d.IsDir():
+			entry.name += "/"
+		case info.Mode()&fs.ModeSymlink != 0:
+			if entry.target, err = os.Readlink(entry.path); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular():
+			// Sockets, devices and named pipes cannot be archived portably
+			return nil
+		}
+		return fn(entry)
+	})
+}
+
+// modTime returns the modification time to record for an entry.
+func (opts ArchiveOptions) modTime(info fs.FileInfo) time.Time {
+	if !opts.ModTime.IsZero() {
+		return opts.ModTime
+	}
+	return info.ModTime()
+}
+
+// WriteTar writes a tar archive of the files, directories and symlinks under root that matcher
+// does not ignore. Ignored directories are skipped entirely, and so is the .git directory. Entries
+// are written in lexical order with their permission bits, without owner information, so the
+// output only depends on the tree's contents and, unless ArchiveOptions.ModTime is set, on its
+// modification times.
+func WriteTar(w io.Writer, root string, matcher *PatternMatcher, opts ArchiveOptions) error {
+	tw := tar.NewWriter(w)
+
+	err := walkArchive(root, matcher, opts, func(entry archiveEntry) error {
+		header, err := tar.FileInfoHeader(entry.info, entry.target)
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+		header.ModTime = opts.modTime(entry.info).Truncate(time.Second)
+		header.AccessTime = time.Time{}
+		header.ChangeTime = time.Time{}
+		header.Uid, header.Gid = 0, 0
+		header.Uname, header.Gname = "", ""
+		header.Format = tar.FormatPAX
+		header.PAXRecords = nil
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		return copyFile(tw, entry.path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write tar archive: %w", err)
+	}
+	return tw.Close()
+}
+
+// WriteTarGz is WriteTar with gzip compression. The gzip header carries no name or timestamp,
+// so the output is as reproducible as the tar stream itself.
+func WriteTarGz(w io.Writer, root string, matcher *PatternMatcher, opts ArchiveOptions) error {
+	gw := gzip.NewWriter(w)
+	if err := WriteTar(gw, root, matcher, opts); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// WriteZip writes a zip archive of the files, directories and symlinks under root that matcher
+// does not ignore, with the same selection and ordering as WriteTar. Files are deflated, and
+// symlinks are stored the way Info-ZIP stores them, as entries whose content is the link target.
+func WriteZip(w io.Writer, root string, matcher *PatternMatcher, opts ArchiveOptions) error {
+	zw := zip.NewWriter(w)
+
+	err := walkArchive(root, matcher, opts, func(entry archiveEntry) error {
+		header, err := zip.FileInfoHeader(entry.info)
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+		header.Modified = opts.modTime(entry.info).UTC().Truncate(time.Second)
+		if entry.info.Mode().IsRegular() {
+			header.Method = zip.Deflate
+		} else {
+			header.Method = zip.Store
+		}
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.info.IsDir():
+			return nil
+		case entry.target != "":
+			_, err := io.WriteString(fw, entry.target)
+			return err
+		default:
+			return copyFile(fw, entry.path)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	return zw.Close()
+}
+
+// copyFile copies the contents of the file at path to w.
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
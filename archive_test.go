@@ -0,0 +1,184 @@
+package dotignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// createArchiveTree creates a tree with regular, executable, ignored and symlinked entries.
+func createArchiveTree(t *testing.T) string {
+	t.Helper()
+	root := createTree(t, "README.md", "bin/run.sh", "build/out.o", "src/main.go", "src/debug.log", ".git/HEAD")
+	if err := os.Chmod(filepath.Join(root, "bin", "run.sh"), 0755); err != nil {
+		t.Fatalf("Failed to change mode: %v", err)
+	}
+	if err := os.Symlink("README.md", filepath.Join(root, "link.md")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	return root
+}
+
+func archiveMatcher(t *testing.T) *PatternMatcher {
+	t.Helper()
+	matcher, err := NewPatternMatcher([]string{"build/", "*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	return matcher
+}
+
+func TestWriteTar(t *testing.T) {
+	root := createArchiveTree(t)
+	matcher := archiveMatcher(t)
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, root, matcher, ArchiveOptions{Prefix: "app", ModTime: modTime}); err != nil {
+		t.Fatalf("WriteTar failed: %v", err)
+	}
+
+	var names []string
+	reader := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read tar: %v", err)
+		}
+		names = append(names, header.Name)
+
+		if !header.ModTime.Equal(modTime) {
+			t.Errorf("Entry %s: expected mod time %v, got %v", header.Name, modTime, header.ModTime)
+		}
+		if header.Uid != 0 || header.Uname != "" {
+			t.Errorf("Entry %s: expected no owner information, got %d/%q", header.Name, header.Uid, header.Uname)
+		}
+
+		switch header.Name {
+		case "app/bin/run.sh":
+			if header.Mode&0111 == 0 {
+				t.Errorf("Expected run.sh to keep its executable bits, got %o", header.Mode)
+			}
+		case "app/link.md":
+			if header.Typeflag != tar.TypeSymlink || header.Linkname != "README.md" {
+				t.Errorf("Expected link.md to be a symlink to README.md, got type %c target %q", header.Typeflag, header.Linkname)
+			}
+		case "app/src/main.go":
+			content, _ := io.ReadAll(reader)
+			if string(content) != "src/main.go" {
+				t.Errorf("Unexpected content for main.go: %q", content)
+			}
+		}
+	}
+
+	expected := []string{"app/README.md", "app/bin/", "app/bin/run.sh", "app/link.md", "app/src/", "app/src/main.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, names)
+	}
+}
+
+func TestWriteTarGzReproducible(t *testing.T) {
+	root := createArchiveTree(t)
+	matcher := archiveMatcher(t)
+	opts := ArchiveOptions{ModTime: time.Unix(0, 0)}
+
+	var first, second bytes.Buffer
+	if err := WriteTarGz(&first, root, matcher, opts); err != nil {
+		t.Fatalf("WriteTarGz failed: %v", err)
+	}
+
+	// Touching files does not change the archive when ModTime is fixed
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "README.md"), later, later); err != nil {
+		t.Fatalf("Failed to change times: %v", err)
+	}
+	if err := WriteTarGz(&second, root, matcher, opts); err != nil {
+		t.Fatalf("WriteTarGz failed: %v", err)
+	}
+
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("Expected identical archives for identical contents")
+	}
+
+	gz, err := gzip.NewReader(&first)
+	if err != nil {
+		t.Fatalf("Failed to read gzip: %v", err)
+	}
+	if gz.Name != "" || !gz.ModTime.IsZero() {
+		t.Errorf("Expected no name or time in the gzip header, got %q %v", gz.Name, gz.ModTime)
+	}
+}
+
+func TestWriteZip(t *testing.T) {
+	root := createArchiveTree(t)
+	matcher := archiveMatcher(t)
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, root, matcher, ArchiveOptions{ModTime: modTime}); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read zip: %v", err)
+	}
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		if !file.Modified.Equal(modTime) {
+			t.Errorf("Entry %s: expected mod time %v, got %v", file.Name, modTime, file.Modified)
+		}
+
+		switch file.Name {
+		case "bin/run.sh":
+			if file.Mode()&0111 == 0 {
+				t.Errorf("Expected run.sh to keep its executable bits, got %v", file.Mode())
+			}
+		case "link.md":
+			if file.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("Expected link.md to be a symlink, got %v", file.Mode())
+			}
+			rc, _ := file.Open()
+			target, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(target) != "README.md" {
+				t.Errorf("Expected symlink target README.md, got %q", target)
+			}
+		}
+	}
+
+	expected := []string{"README.md", "bin/", "bin/run.sh", "link.md", "src/", "src/main.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, names)
+	}
+
+	var again bytes.Buffer
+	if err := WriteZip(&again, root, matcher, ArchiveOptions{ModTime: modTime}); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("Expected identical zip archives for identical contents")
+	}
+}
+
+func TestWriteArchiveErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, t.TempDir(), nil, ArchiveOptions{}); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+	if err := WriteZip(&buf, filepath.Join(t.TempDir(), "missing"), archiveMatcher(t), ArchiveOptions{}); err == nil {
+		t.Error("Expected error for missing root")
+	}
+}
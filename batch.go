@@ -0,0 +1,96 @@
+package dotignore
+
+import (
+	"sync"
+)
+
+// MatchAll checks every path in paths and returns a slice where the i-th element reports whether
+// paths[i] should be ignored. It gives the same results as calling Matches for each path, but
+// reuses scratch buffers between paths and, when the matcher was created with WithWorkers,
+// splits the list across that many goroutines. The first error encountered is returned.
+func (p *PatternMatcher) MatchAll(paths []string) ([]bool, error) {
+	results := make([]bool, len(paths))
+
+	workers := p.options.workers
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	if workers <= 1 {
+		return results, p.matchRange(paths, results)
+	}
+
+	chunk := (len(paths) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunk
+		end := start + chunk
+		if end > len(paths) {
+			end = len(paths)
+		}
+		if start >= end {
+			break
+		}
+
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			errs[w] = p.matchRange(paths[start:end], results[start:end])
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// matchRange fills results for paths using a single reusable pathInfo.
+func (p *PatternMatcher) matchRange(paths []string, results []bool) error {
+	var info pathInfo
+	for i, path := range paths {
+		matched, err := p.matchWithBuffer(&info, path, true)
+		if err != nil {
+			return err
+		}
+		results[i] = matched
+	}
+	return nil
+}
+
+// Filter reads paths from in and sends the ones that should not be ignored to the returned
+// channel, which is closed once in is closed and every path has been processed. When the matcher
+// was created with WithWorkers, paths are evaluated concurrently and may be delivered in a
+// different order than they were received. Paths that cannot be evaluated, such as paths over the
+// Limits or outside the root, are dropped as if they were ignored, so that they never slip through.
+func (p *PatternMatcher) Filter(in <-chan string) <-chan string {
+	out := make(chan string)
+
+	workers := p.options.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var info pathInfo
+			for path := range in {
+				if matched, err := p.matchWithBuffer(&info, path, true); err == nil && !matched {
+					out <- path
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
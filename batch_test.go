@@ -0,0 +1,173 @@
+package dotignore
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+var batchPatterns = []string{
+	"*.log", "!important.log", "build/", "**/node_modules/", "src/*.tmp", "docs/**",
+}
+
+var batchPaths = []string{
+	"app.log", "important.log", "build/app.js", "a/node_modules/x.js", "src/a.tmp",
+	"src/main.go", "docs/guide.md", "", ".", "README.md", "build\\out.js", "deep/nested/file.log",
+}
+
+func TestMatchAll(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 64} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			matcher, err := NewPatternMatcher(batchPatterns, WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			results, err := matcher.MatchAll(batchPaths)
+			if err != nil {
+				t.Fatalf("MatchAll failed: %v", err)
+			}
+			if len(results) != len(batchPaths) {
+				t.Fatalf("Expected %d results, got %d", len(batchPaths), len(results))
+			}
+
+			for i, path := range batchPaths {
+				expected, err := matcher.Matches(path)
+				if err != nil {
+					t.Fatalf("Matches failed: %v", err)
+				}
+				if results[i] != expected {
+					t.Errorf("Path %q: MatchAll returned %v, Matches returned %v", path, results[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchAllEmpty(t *testing.T) {
+	matcher, err := NewPatternMatcher(batchPatterns, WithWorkers(4))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	results, err := matcher.MatchAll(nil)
+	if err != nil {
+		t.Fatalf("MatchAll failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			matcher, err := NewPatternMatcher(batchPatterns, WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			in := make(chan string)
+			go func() {
+				defer close(in)
+				for _, path := range batchPaths {
+					in <- path
+				}
+			}()
+
+			var kept []string
+			for path := range matcher.Filter(in) {
+				kept = append(kept, path)
+			}
+
+			var expected []string
+			for _, path := range batchPaths {
+				if matched, _ := matcher.Matches(path); !matched {
+					expected = append(expected, path)
+				}
+			}
+
+			sort.Strings(kept)
+			sort.Strings(expected)
+			if !reflect.DeepEqual(kept, expected) {
+				t.Errorf("Expected %v, got %v", expected, kept)
+			}
+		})
+	}
+}
+
+func TestFilterDropsErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"}, WithLimits(Limits{MaxPathDepth: 2}), WithRoot(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	in := make(chan string, 4)
+	in <- "a/b.go"
+	in <- "a/b/c.go"
+	in <- "../outside.go"
+	in <- "debug.log"
+	close(in)
+
+	var kept []string
+	for path := range matcher.Filter(in) {
+		kept = append(kept, path)
+	}
+	if !reflect.DeepEqual(kept, []string{"a/b.go"}) {
+		t.Errorf("Expected [a/b.go], got %v", kept)
+	}
+}
+
+func TestFilterZeroValueMatcher(t *testing.T) {
+	var matcher PatternMatcher
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		in <- "a.txt"
+		in <- "b.txt"
+	}()
+
+	done := make(chan []string)
+	go func() {
+		var kept []string
+		for path := range matcher.Filter(in) {
+			kept = append(kept, path)
+		}
+		done <- kept
+	}()
+
+	select {
+	case kept := <-done:
+		if !reflect.DeepEqual(kept, []string{"a.txt", "b.txt"}) {
+			t.Errorf("Expected [a.txt b.txt], got %v", kept)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Filter did not consume its input")
+	}
+}
+
+func BenchmarkMatchAll(b *testing.B) {
+	paths := make([]string, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		paths = append(paths, fmt.Sprintf("src/pkg%d/sub/file%d.go", i%100, i))
+	}
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			matcher, err := NewPatternMatcher(batchPatterns, WithWorkers(workers))
+			if err != nil {
+				b.Fatalf("Failed to create matcher: %v", err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := matcher.MatchAll(paths); err != nil {
+					b.Fatalf("MatchAll failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package dotignore
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// byteRuneBase is the rune that byte 0x00 would map to in byte mode; bytes 0x80 to 0xff map to
+// byteRuneBase+0x80 to byteRuneBase+0xff. These lie in the Unicode private use area, whose
+// characters are neither spaces nor cased letters, so no string function treats them specially.
+const byteRuneBase = 0xE000
+
+// MatchesBytes is like MatchesPath, but takes the path as raw bytes, as Linux file systems store
+// names, and matches it byte by byte as git does. A "?" or a bracket expression matches exactly
+// one byte, so the Latin-1 name "caf\xe9" matches "caf?" while the UTF-8 name "café", which spells
+// "é" with two bytes, does not. Patterns are matched against their UTF-8 encoding, and patterns
+// that are not valid UTF-8, such as the lines of a Latin-1 ignore file, against their raw bytes;
+// only MatchesBytes and Status apply the latter. Paths do not need to be valid UTF-8, and
+// WithUnicodeNormalization does not apply to them.
+func (p *PatternMatcher) MatchesBytes(file []byte, isDir bool) (bool, error) {
+	name, ok, err := p.relativePath(string(file))
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := p.checkPath(name); err != nil {
+		return false, err
+	}
+
+	matcher, err := p.byteMatcher()
+	if err != nil {
+		return false, err
+	}
+	return matcher.MatchesPath(toByteRunes(name), isDir)
+}
+
+// byteMatcher returns the matcher MatchesBytes delegates to. It holds the patterns of p with every
+// byte mapped to its own rune by toByteRunes, so that the regular expressions, which work on runes,
+// match single bytes. It is built on first use.
+func (p *PatternMatcher) byteMatcher() (*PatternMatcher, error) {
+	p.bytesOnce.Do(func() {
+		patterns := make([]ignorePattern, 0, len(p.ignorePatterns))
+		for _, pattern := range p.ignorePatterns {
+			parsed, _, err := parsePattern(toByteRunes(pattern.text), pattern.line)
+			if err != nil {
+				p.bytesErr = withFile(err, p.options.sourceFile)
+				return
+			}
+			parsed.text = pattern.text
+			patterns = append(patterns, parsed)
+		}
+
+		opts := p.options
+		opts.normalize = false
+		// Paths are made relative to the root before they are mapped
+		opts.root = ""
+		// Path limits are checked against the raw bytes, which the mapping makes longer
+		opts.limits.MaxPathLength = 0
+		opts.limits.MaxPathDepth = 0
+		if opts.tracked != nil {
+			opts.tracked = byteTrackedSet{opts.tracked}
+		}
+		p.bytes = &PatternMatcher{
+			ignorePatterns: patterns,
+			options:        opts,
+			cache:          newResultCache(opts.cacheSize),
+		}
+	})
+	return p.bytes, p.bytesErr
+}
+
+// byteTrackedSet looks up paths of the byte matcher in a TrackedSet by their original bytes.
+type byteTrackedSet struct {
+	set TrackedSet
+}
+
+func (s byteTrackedSet) Tracked(path string) bool {
+	return s.set.Tracked(fromByteRunes(path))
+}
+
+// toByteRunes maps every byte of s to a rune of its own: ASCII bytes to themselves, and bytes
+// 0x80 to 0xff into the private use area. The result is valid UTF-8 whatever s holds.
+func toByteRunes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < utf8.RuneSelf {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(byteRuneBase + rune(c))
+		}
+	}
+	return b.String()
+}
+
+// fromByteRunes reverses toByteRunes.
+func fromByteRunes(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= byteRuneBase+utf8.RuneSelf && r <= byteRuneBase+0xff {
+			b.WriteByte(byte(r - byteRuneBase))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
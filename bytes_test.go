@@ -0,0 +1,147 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchesBytes(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"caf?", "x[é]", "n[!a]", "*.txt", "out?/", "!keep\xc3\xa9.txt"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"question mark matches a Latin-1 byte", "caf\xe9", false, true},
+		{"question mark does not match a two-byte UTF-8 character", "caf\xc3\xa9", false, false},
+		{"class matches a single byte of its UTF-8 pattern", "x\xc3", false, true},
+		{"class does not match the UTF-8 character", "x\xc3\xa9", false, false},
+		{"class does not match a Latin-1 byte", "x\xe9", false, false},
+		{"negated class matches a Latin-1 byte", "n\xe9", false, true},
+		{"negated class does not match two bytes", "n\xc3\xa9", false, false},
+		{"star matches Latin-1 bytes", "r\xe9sum\xe9.txt", false, true},
+		{"directory pattern matches file below it", "out\xff/data", false, true},
+		{"directory pattern needs a directory", "out\xff", false, false},
+		{"negation matches UTF-8 bytes", "keep\xc3\xa9.txt", false, false},
+		{"negation does not match Latin-1", "keep\xe9.txt", false, true},
+		{"backslashes are separators", "dir\\caf\xe9", false, true},
+		{"root", ".", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ignored, err := matcher.MatchesBytes([]byte(test.path), test.isDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ignored != test.expected {
+				t.Errorf("Expected MatchesBytes(%q) to be %v, got %v", test.path, test.expected, ignored)
+			}
+		})
+	}
+
+	// Matches works on runes, where "?" matches the whole "é"
+	if ignored, _ := matcher.Matches("caf\xc3\xa9"); !ignored {
+		t.Error("Expected Matches to match a UTF-8 character with '?'")
+	}
+}
+
+func TestMatchesBytesLatin1Patterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("caf\xe9/\n*.r\xe9s\n!keep.r\xe9s\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	matcher, err := NewPatternMatcherFromFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"caf\xe9/x", false, true},
+		{"caf\xe9", true, true},
+		{"caf\xe9", false, false},
+		{"caf\xc3\xa9/x", false, false},
+		{"notes.r\xe9s", false, true},
+		{"keep.r\xe9s", false, false},
+	}
+
+	for _, test := range tests {
+		ignored, err := matcher.MatchesBytes([]byte(test.path), test.isDir)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ignored != test.expected {
+			t.Errorf("Expected MatchesBytes(%q) to be %v, got %v", test.path, test.expected, ignored)
+		}
+	}
+
+	// Patterns that are not valid UTF-8 name raw bytes, which MatchesPath does not match
+	if ignored, _ := matcher.MatchesPath("caf\xc3\xa9/x", false); ignored {
+		t.Error("Expected MatchesPath to skip the Latin-1 pattern")
+	}
+
+	lenient, diagnostics := NewPatternMatcherLenient([]string{"caf\xe9/"})
+	if ignored, _ := lenient.MatchesBytes([]byte("caf\xe9/x"), false); !ignored {
+		t.Error("Expected the lenient matcher to apply the Latin-1 pattern")
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning || diagnostics[0].Column != 4 || !errors.Is(diagnostics[0].Reason, ErrInvalidUTF8) {
+		t.Errorf("Expected an ErrInvalidUTF8 warning at column 4, got %v", diagnostics)
+	}
+}
+
+func TestMatchesBytesOptions(t *testing.T) {
+	tracked := trackedPaths{"caf\xe9": true}
+	limits := Limits{MaxPathLength: 8}
+	matcher, err := NewPatternMatcher([]string{"caf*"}, WithTracked(tracked), WithLimits(limits), WithCache(16))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if ignored, err := matcher.MatchesBytes([]byte("caf\xe9"), false); err != nil || ignored {
+		t.Errorf("Expected tracked Latin-1 path not to be ignored, got %v (%v)", ignored, err)
+	}
+	for i := 0; i < 2; i++ {
+		if ignored, err := matcher.MatchesBytes([]byte("caf\xe8"), false); err != nil || !ignored {
+			t.Errorf("Expected untracked Latin-1 path to be ignored, got %v (%v)", ignored, err)
+		}
+	}
+
+	// The limit applies to the raw bytes: 8 bytes pass, 9 do not
+	if _, err := matcher.MatchesBytes([]byte(strings.Repeat("\xe9", 8)), false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := matcher.MatchesBytes([]byte(strings.Repeat("\xe9", 9)), false); !errors.Is(err, ErrPathTooLong) {
+		t.Errorf("Expected ErrPathTooLong, got %v", err)
+	}
+}
+
+// trackedPaths is a TrackedSet of exact paths.
+type trackedPaths map[string]bool
+
+func (s trackedPaths) Tracked(path string) bool {
+	return s[path]
+}
+
+func TestByteRunes(t *testing.T) {
+	for _, s := range []string{"", "ascii/path", "caf\xe9", "caf\xc3\xa9", "\x80\xff\x00", "\xe9\xe9/\xa0"} {
+		mapped := toByteRunes(s)
+		if strings.TrimSpace(mapped) != mapped {
+			t.Errorf("Expected no spaces at the ends of toByteRunes(%q) = %q", s, mapped)
+		}
+		if back := fromByteRunes(mapped); back != s {
+			t.Errorf("Expected fromByteRunes(toByteRunes(%q)) to round-trip, got %q", s, back)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package dotignore
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// CacheStats reports the effectiveness of a matcher's result cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Len is the number of results currently cached.
+	Len int
+	// Capacity is the maximum number of results the cache holds.
+	Capacity int
+}
+
+// CacheStats returns the hit and miss counters of the result cache enabled with WithCache.
+// It returns the zero value if the matcher has no cache.
+func (p *PatternMatcher) CacheStats() CacheStats {
+	if p.cache == nil {
+		return CacheStats{}
+	}
+	return p.cache.stats()
+}
+
+// cacheKey identifies a cached result.
+type cacheKey struct {
+	path  string
+	isDir bool
+}
+
+// cacheEntry is the value stored in the recency list.
+type cacheEntry struct {
+	key     cacheKey
+	matched bool
+}
+
+// resultCache is a concurrency-safe least-recently-used cache of match results.
+type resultCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	recency *list.List // front is most recently used
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// newResultCache returns a cache holding up to capacity results, or nil if capacity is not positive.
+func newResultCache(capacity int) *resultCache {
+	if capacity < 1 {
+		return nil
+	}
+	return &resultCache{
+		capacity: capacity,
+		entries:  make(map[cacheKey]*list.Element, capacity),
+		recency:  list.New(),
+	}
+}
+
+// get returns the cached result for a normalized path.
+func (c *resultCache) get(path string, isDir bool) (bool, bool) {
+	c.mu.Lock()
+	element, ok := c.entries[cacheKey{path: path, isDir: isDir}]
+	matched := false
+	if ok {
+		c.recency.MoveToFront(element)
+		matched = element.Value.(*cacheEntry).matched
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.misses.Add(1)
+		return false, false
+	}
+	c.hits.Add(1)
+	return matched, true
+}
+
+// add stores the result for a normalized path, evicting the least recently used entry when full.
+func (c *resultCache) add(path string, isDir bool, matched bool) {
+	key := cacheKey{path: path, isDir: isDir}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).matched = matched
+		c.recency.MoveToFront(element)
+		return
+	}
+
+	if c.recency.Len() >= c.capacity {
+		oldest := c.recency.Back()
+		c.recency.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+	c.entries[key] = c.recency.PushFront(&cacheEntry{key: key, matched: matched})
+}
+
+// stats returns a snapshot of the cache counters.
+func (c *resultCache) stats() CacheStats {
+	c.mu.Lock()
+	length := c.recency.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Len:      length,
+		Capacity: c.capacity,
+	}
+}
@@ -0,0 +1,108 @@
+package dotignore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestResultCache(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/"}, WithCache(2))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	for _, file := range []string{"app.log", "./app.log", "app.log"} {
+		if matched, _ := matcher.Matches(file); !matched {
+			t.Errorf("Expected %s to be ignored", file)
+		}
+	}
+
+	stats := matcher.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss, got %+v", stats)
+	}
+
+	// Directory and non-directory lookups are cached separately
+	if matched, _ := matcher.MatchesPath("build", true); !matched {
+		t.Error("Expected directory build to be ignored")
+	}
+	if matched, _ := matcher.MatchesPath("build", false); matched {
+		t.Error("Expected file build not to be ignored")
+	}
+
+	stats = matcher.CacheStats()
+	if stats.Len != 2 || stats.Capacity != 2 {
+		t.Errorf("Expected a full cache of 2 entries, got %+v", stats)
+	}
+
+	// app.log was evicted as the least recently used entry
+	matcher.Matches("app.log")
+	if stats := matcher.CacheStats(); stats.Misses != 4 {
+		t.Errorf("Expected app.log to be a miss after eviction, got %+v", stats)
+	}
+}
+
+func TestResultCacheDisabled(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	matcher.Matches("app.log")
+	if stats := matcher.CacheStats(); stats != (CacheStats{}) {
+		t.Errorf("Expected empty stats without a cache, got %+v", stats)
+	}
+}
+
+func TestResultCacheInvalidatedOnReload(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"}, WithCache(16))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	reloadable := NewReloadableMatcher(matcher)
+
+	if matched, _ := reloadable.Matches("app.log"); !matched {
+		t.Error("Expected app.log to be ignored before reload")
+	}
+	if err := reloadable.Reload([]string{"*.tmp"}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if matched, _ := reloadable.Matches("app.log"); matched {
+		t.Error("Expected stale cached result to be discarded after reload")
+	}
+
+	stats := reloadable.Load().CacheStats()
+	if stats.Capacity != 16 || stats.Hits != 0 {
+		t.Errorf("Expected a fresh cache with the same capacity, got %+v", stats)
+	}
+}
+
+// TestResultCacheConcurrent is meant to be run with -race.
+func TestResultCacheConcurrent(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!keep.log"}, WithCache(8))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				file := fmt.Sprintf("dir%d/file%d.log", i, j%16)
+				if matched, _ := matcher.Matches(file); !matched {
+					t.Errorf("Expected %s to be ignored", file)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := matcher.CacheStats()
+	if stats.Hits+stats.Misses != 8*200 {
+		t.Errorf("Expected %d lookups, got %+v", 8*200, stats)
+	}
+}
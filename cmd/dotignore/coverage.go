@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/codeglyph/go-dotignore"
+)
+
+// runCoverage implements "dotignore coverage [-all] [-paths file] ignorefile [dir]".
+func runCoverage(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("coverage", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	all := flags.Bool("all", false, "print match counts for every rule, not only the unmatched ones")
+	pathsFile := flags.String("paths", "", "read the paths to evaluate from `file`, one per line (\"-\" for standard input), instead of walking dir")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dotignore coverage [-all] [-paths file] ignorefile [dir]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Evaluates the files under dir (default \".\") against ignorefile and lists the rules")
+		fmt.Fprintln(stderr, "that matched nothing. Exits with status 1 if there are any.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 || (flags.NArg() == 2 && *pathsFile != "") {
+		flags.Usage()
+		return 2
+	}
+
+	ignoreFile := flags.Arg(0)
+	matcher, err := dotignore.NewPatternMatcherFromFile(ignoreFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore: %v\n", err)
+		return 2
+	}
+
+	var report dotignore.CoverageReport
+	if *pathsFile != "" {
+		paths, err := readPathList(*pathsFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+		report, err = dotignore.CoverPaths(matcher, paths)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+	} else {
+		dir := "."
+		if flags.NArg() == 2 {
+			dir = flags.Arg(1)
+		}
+		report, err = dotignore.CoverTree(matcher, dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+	}
+
+	if *all {
+		for _, rule := range report.Rules {
+			fmt.Fprintf(stdout, "%s:%d: %s matched=%d decisive=%d\n", ignoreFile, rule.Line, rule.Pattern, rule.Matched, rule.Decisive)
+		}
+	} else {
+		for _, rule := range report.Unmatched() {
+			fmt.Fprintf(stdout, "%s:%d: %s\n", ignoreFile, rule.Line, rule.Pattern)
+		}
+	}
+
+	if len(report.Unmatched()) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codeglyph/go-dotignore"
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+// runDiff implements "dotignore diff [-paths file] old new [dir]".
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pathsFile := flags.String("paths", "", "read the paths to compare from `file`, one per line (\"-\" for standard input), instead of walking dir")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dotignore diff [-paths file] old new [dir]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Compares two ignore files against the files under dir (default \".\") and prints")
+		fmt.Fprintln(stderr, "\"+ path\" for newly ignored and \"- path\" for newly un-ignored paths.")
+		fmt.Fprintln(stderr, "Exits with status 1 if any path changed status.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() < 2 || flags.NArg() > 3 || (flags.NArg() == 3 && *pathsFile != "") {
+		flags.Usage()
+		return 2
+	}
+
+	oldMatcher, err := dotignore.NewPatternMatcherFromFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore: %v\n", err)
+		return 2
+	}
+	newMatcher, err := dotignore.NewPatternMatcherFromFile(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "dotignore: %v\n", err)
+		return 2
+	}
+
+	var result dotignore.DiffResult
+	if *pathsFile != "" {
+		paths, err := readPathList(*pathsFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+		result, err = dotignore.Diff(oldMatcher, newMatcher, paths)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+	} else {
+		dir := "."
+		if flags.NArg() == 3 {
+			dir = flags.Arg(2)
+		}
+		result, err = dotignore.DiffTree(oldMatcher, newMatcher, dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			return 2
+		}
+	}
+
+	for _, path := range result.NewlyIgnored {
+		fmt.Fprintf(stdout, "+ %s\n", path)
+	}
+	for _, path := range result.NewlyUnignored {
+		fmt.Fprintf(stdout, "- %s\n", path)
+	}
+	if !result.Empty() {
+		return 1
+	}
+	return 0
+}
+
+// readPathList reads non-empty lines from a file, or from standard input if name is "-".
+func readPathList(name string) ([]string, error) {
+	reader := io.Reader(os.Stdin)
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	lines, err := internal.ReadLines(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read paths from %q: %w", name, err)
+	}
+
+	paths := lines[:0]
+	for _, line := range lines {
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
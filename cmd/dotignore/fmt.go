@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codeglyph/go-dotignore/format"
+)
+
+// runFmt implements "dotignore fmt [-check] [-w] [-sort] [file ...]".
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	check := flags.Bool("check", false, "list files whose formatting differs and exit with status 1, without writing")
+	write := flags.Bool("w", false, "write the result back to the file instead of standard output")
+	sortPatterns := flags.Bool("sort", false, "sort patterns within each section")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dotignore fmt [-check] [-w] [-sort] [file ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintf(stderr, "Rewrites the given ignore files, or %s if none are given, in canonical form.\n", defaultIgnoreFile)
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{defaultIgnoreFile}
+	}
+
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			status = 1
+			continue
+		}
+
+		formatted, err := format.Format(src, format.Options{Sort: *sortPatterns})
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %s: %v\n", file, err)
+			status = 1
+			continue
+		}
+
+		switch {
+		case *check:
+			if !bytes.Equal(src, formatted) {
+				fmt.Fprintln(stdout, file)
+				status = 1
+			}
+		case *write:
+			if bytes.Equal(src, formatted) {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				fmt.Fprintf(stderr, "dotignore: %v\n", err)
+				status = 1
+				continue
+			}
+			if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+				fmt.Fprintf(stderr, "dotignore: %v\n", err)
+				status = 1
+			}
+		default:
+			stdout.Write(formatted)
+		}
+	}
+	return status
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/codeglyph/go-dotignore"
+	"github.com/codeglyph/go-dotignore/lint"
+)
+
+// runLint implements "dotignore lint [-errors] [file ...]".
+func runLint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	errorsOnly := flags.Bool("errors", false, "report only lines that cannot be parsed")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dotignore lint [-errors] [file ...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintf(stderr, "Lints the given ignore files, or %s if none are given.\n", defaultIgnoreFile)
+		fmt.Fprintln(stderr, "Exits with status 1 if any problems are reported.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{defaultIgnoreFile}
+	}
+
+	status := 0
+	for _, file := range files {
+		findings, err := lint.LintFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "dotignore: %v\n", err)
+			status = 1
+			continue
+		}
+
+		for _, finding := range findings {
+			if *errorsOnly && finding.Severity != dotignore.SeverityError {
+				continue
+			}
+			fmt.Fprintln(stdout, finding)
+			status = 1
+		}
+	}
+	return status
+}
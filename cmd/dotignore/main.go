@@ -0,0 +1,73 @@
+// Command dotignore inspects and maintains .gitignore-style files.
+//
+// Usage:
+//
+//	dotignore <command> [flags] [arguments]
+//
+// Run "dotignore help" for the list of commands.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// defaultIgnoreFile is used by commands when no file is given.
+const defaultIgnoreFile = ".gitignore"
+
+// command is a dotignore subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands lists the available subcommands in the order they are shown in the usage message.
+var commands = []command{
+	{name: "lint", summary: "report duplicate, shadowed and ineffective rules", run: runLint},
+	{name: "fmt", summary: "rewrite ignore files in canonical form", run: runFmt},
+	{name: "diff", summary: "list paths whose status changes between two ignore files", run: runDiff},
+	{name: "coverage", summary: "list rules that match no path in a tree", run: runCoverage},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the process exit code: 0 on success, 1 when a
+// command reports problems and 2 for usage errors.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "dotignore: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dotignore <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "dotignore <command> -h" for the flags of a command.`)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates a file with the given content inside dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	return path
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 without arguments, got %d", code)
+	}
+	if code := run([]string{"help"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for help, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "lint") {
+		t.Errorf("Expected usage to list the lint command, got %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for unknown command, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command "bogus"`) {
+		t.Errorf("Expected unknown command message, got %q", stderr.String())
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	clean := writeFile(t, dir, "clean.gitignore", "*.log\nbuild/\n")
+	dirty := writeFile(t, dir, "dirty.gitignore", "*.log\n*.log\n!\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", clean}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for a clean file, got %d: %s", code, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"lint", dirty}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a file with problems, got %d", code)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 findings, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"lint", "-errors", dirty}, &stdout, &stderr)
+	if lines := strings.Count(stdout.String(), "\n"); lines != 1 || !strings.Contains(stdout.String(), "error") {
+		t.Errorf("Expected only the parse error with -errors, got %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"lint", filepath.Join(dir, "missing")}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a missing file, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "failed to open") {
+		t.Errorf("Expected an open error, got %q", stderr.String())
+	}
+}
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	canonical := writeFile(t, dir, "canonical.gitignore", "*.log\nbuild/\n")
+	messy := writeFile(t, dir, "messy.gitignore", "  *.log\n*.log\n\n\nbuild\\\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"fmt", "-check", canonical}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for a canonical file, got %d", code)
+	}
+	if code := run([]string{"fmt", "-check", canonical, messy}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 when formatting would change, got %d", code)
+	}
+	if stdout.String() != messy+"\n" {
+		t.Errorf("Expected -check to list only %s, got %q", messy, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"fmt", messy}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if expected := "*.log\n\nbuild/\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	if code := run([]string{"fmt", "-w", messy}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for -w, got %d", code)
+	}
+	if content, _ := os.ReadFile(messy); string(content) != "*.log\n\nbuild/\n" {
+		t.Errorf("Expected file to be rewritten, got %q", content)
+	}
+	if code := run([]string{"fmt", "-check", messy}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected the rewritten file to pass -check, got %d", code)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldRules := writeFile(t, dir, "old.gitignore", "*.log\nbuild/\n")
+	newRules := writeFile(t, dir, "new.gitignore", "*.log\ndist/\n")
+	tree := filepath.Join(dir, "tree")
+	writeFile(t, tree, "build/app.js", "")
+	writeFile(t, tree, "dist/bundle.js", "")
+	writeFile(t, tree, "app.log", "")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"diff", oldRules, newRules, tree}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 when paths change status, got %d: %s", code, stderr.String())
+	}
+	if expected := "+ dist/bundle.js\n- build/app.js\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	paths := writeFile(t, dir, "paths.txt", "app.log\n\nsrc/main.go\n")
+	stdout.Reset()
+	if code := run([]string{"diff", "-paths", paths, oldRules, newRules}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 when nothing changes, got %d", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, got %q", stdout.String())
+	}
+
+	if code := run([]string{"diff", oldRules}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for missing arguments, got %d", code)
+	}
+}
+
+func TestRunCoverage(t *testing.T) {
+	dir := t.TempDir()
+	rules := writeFile(t, dir, "rules.gitignore", "*.log\n*.bak\n")
+	tree := filepath.Join(dir, "tree")
+	writeFile(t, tree, "app.log", "")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"coverage", rules, tree}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 with unmatched rules, got %d: %s", code, stderr.String())
+	}
+	if expected := rules + ":2: *.bak\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"coverage", "-all", rules, tree}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), rules+":1: *.log matched=1 decisive=1") {
+		t.Errorf("Expected counts for every rule, got %q", stdout.String())
+	}
+
+	if code := run([]string{"coverage"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for missing arguments, got %d", code)
+	}
+}
@@ -0,0 +1,166 @@
+package dotignore
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/internal/conformance"
+)
+
+//go:generate go run ./internal/conformance/gen testdata/conformance
+
+var updateDivergences = flag.Bool("update-divergences", false, "rewrite the list of known divergences from git")
+
+// divergencesFile lists, one per line, the mode, case and path for which a mode of the matcher
+// knowingly disagrees with git. The suite fails when a new divergence appears or a listed one is
+// fixed; run the tests with -update-divergences to rewrite the list.
+var divergencesFile = filepath.Join("testdata", "conformance", "KNOWN_DIVERGENCES")
+
+// conformanceModes evaluates every path of a case in one mode of the matcher and returns whether
+// each path is ignored.
+var conformanceModes = []struct {
+	name     string
+	classify func(t *testing.T, c conformance.Case, matcher *PatternMatcher) map[string]bool
+}{
+	{"MatchesPath", func(t *testing.T, c conformance.Case, matcher *PatternMatcher) map[string]bool {
+		result := make(map[string]bool)
+		for _, path := range c.Paths {
+			name := strings.TrimSuffix(path, "/")
+			ignored, err := matcher.MatchesPath(name, name != path || isParentDir(c, name))
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", path, err)
+			}
+			result[path] = ignored
+		}
+		return result
+	}},
+	{"Matches", func(t *testing.T, c conformance.Case, matcher *PatternMatcher) map[string]bool {
+		result := make(map[string]bool)
+		for _, path := range c.Paths {
+			ignored, err := matcher.Matches(strings.TrimSuffix(path, "/"))
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", path, err)
+			}
+			result[path] = ignored
+		}
+		return result
+	}},
+	{"MatchesBytes", func(t *testing.T, c conformance.Case, matcher *PatternMatcher) map[string]bool {
+		result := make(map[string]bool)
+		for _, path := range c.Paths {
+			name := strings.TrimSuffix(path, "/")
+			ignored, err := matcher.MatchesBytes([]byte(name), name != path || isParentDir(c, name))
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", path, err)
+			}
+			result[path] = ignored
+		}
+		return result
+	}},
+	{"Cursor", func(t *testing.T, c conformance.Case, matcher *PatternMatcher) map[string]bool {
+		paths := append([]string(nil), c.Paths...)
+		sort.Strings(paths)
+		cursor := matcher.NewCursor()
+		result := make(map[string]bool)
+		for _, path := range paths {
+			ignored, err := cursor.Matches(strings.TrimSuffix(path, "/"))
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", path, err)
+			}
+			result[path] = ignored
+		}
+		return result
+	}},
+	{"Status", func(t *testing.T, c conformance.Case, _ *PatternMatcher) map[string]bool {
+		root := t.TempDir()
+		if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := c.CreateTree(root); err != nil {
+			t.Fatalf("Failed to create tree: %v", err)
+		}
+		entries, err := Status(root, StatusOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		result := make(map[string]bool)
+		for _, path := range c.Paths {
+			name := strings.TrimSuffix(path, "/")
+			for _, entry := range entries {
+				if entry.Status == StatusIgnored && (entry.Path == name || entry.IsDir && strings.HasPrefix(name, entry.Path+"/")) {
+					result[path] = true
+				}
+			}
+		}
+		return result
+	}},
+}
+
+// isParentDir reports whether name is created as a directory because another path of the case
+// lies below it.
+func isParentDir(c conformance.Case, name string) bool {
+	for _, path := range c.Paths {
+		if strings.HasPrefix(path, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConformance(t *testing.T) {
+	cases, err := conformance.Load(filepath.Join("testdata", "conformance"))
+	if err != nil {
+		t.Fatalf("Failed to load conformance cases: %v", err)
+	}
+
+	var divergences []string
+	for _, mode := range conformanceModes {
+		for _, c := range cases {
+			matcher, _ := NewPatternMatcherLenient(c.Patterns())
+			result := mode.classify(t, c, matcher)
+			for _, path := range c.Paths {
+				if result[path] != c.IsIgnored(path) {
+					divergences = append(divergences, mode.name+" "+c.Name+" "+strings.ReplaceAll(path, " ", "\\ "))
+				}
+			}
+		}
+	}
+
+	if *updateDivergences {
+		content := "# Generated by go test -run TestConformance -update-divergences; do not edit.\n" +
+			"# mode case path, where the matcher disagrees with git check-ignore.\n"
+		if len(divergences) > 0 {
+			content += strings.Join(divergences, "\n") + "\n"
+		}
+		if err := os.WriteFile(divergencesFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write known divergences: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(divergencesFile)
+	if err != nil {
+		t.Fatalf("Failed to read known divergences: %v", err)
+	}
+	known := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			known[line] = true
+		}
+	}
+
+	for _, divergence := range divergences {
+		if !known[divergence] {
+			t.Errorf("New divergence from git: %s", divergence)
+		}
+		delete(known, divergence)
+	}
+	for divergence := range known {
+		t.Errorf("Known divergence no longer occurs, update %s: %s", divergencesFile, divergence)
+	}
+}
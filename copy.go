@@ -0,0 +1,400 @@
+package dotignore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompareMode selects how CopyTree decides that a destination file is already up to date.
+type CompareMode int
+
+const (
+	// CompareNone copies every file.
+	CompareNone CompareMode = iota
+	// CompareSizeModTime skips files whose size and modification time match the source.
+	CompareSizeModTime
+	// CompareHash skips files whose contents match the source, comparing SHA-256 digests.
+	CompareHash
+)
+
+// CopyOptions controls CopyTree.
+type CopyOptions struct {
+	// Compare selects how unchanged files are detected and skipped.
+	Compare CompareMode
+	// Delete removes files and directories from the destination that are not part of the
+	// filtered source tree, including ones the matcher ignores.
+	Delete bool
+	// DryRun reports the actions that would be taken without changing the destination.
+	DryRun bool
+}
+
+// CopyActionKind identifies what CopyTree did to a path.
+type CopyActionKind int
+
+const (
+	// ActionMkdir creates a directory.
+	ActionMkdir CopyActionKind = iota + 1
+	// ActionCopy copies a file.
+	ActionCopy
+	// ActionSymlink creates a symbolic link.
+	ActionSymlink
+	// ActionDelete removes a path, and everything below it for directories.
+	ActionDelete
+)
+
+func (k CopyActionKind) String() string {
+	switch k {
+	case ActionMkdir:
+		return "mkdir"
+	case ActionCopy:
+		return "copy"
+	case ActionSymlink:
+		return "symlink"
+	case ActionDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("CopyActionKind(%d)", int(k))
+	}
+}
+
+// CopyAction is a change CopyTree made, or would make in dry-run mode, to the destination.
+type CopyAction struct {
+	Kind CopyActionKind
+	// Path is relative to the destination and uses forward slashes.
+	Path string
+}
+
+func (a CopyAction) String() string {
+	return a.Kind.String() + " " + a.Path
+}
+
+// CopyTree mirrors the files, directories and symlinks under src that matcher does not ignore
+// into dst, creating dst if needed. Ignored directories are skipped entirely, and so is the .git
+// directory. Copied files keep their permission bits and modification times, so a later run with
+// CompareSizeModTime recognizes them as unchanged. Unchanged files are not reported. It returns
+// the actions taken, in the order they were taken. src and dst must not overlap: CopyTree fails
+// if they are the same directory or one lies below the other, after resolving symbolic links.
+func CopyTree(src, dst string, matcher *PatternMatcher, opts CopyOptions) ([]CopyAction, error) {
+	if src == "" || dst == "" {
+		return nil, errors.New("source and destination cannot be empty")
+	}
+	if matcher == nil {
+		return nil, errors.New("matcher cannot be nil")
+	}
+	if err := checkOverlap(src, dst); err != nil {
+		return nil, err
+	}
+
+	c := &treeCopier{dst: dst, opts: opts, kept: make(map[string]bool), planned: make(map[string]bool)}
+	if err := c.ensureDir(".", src); err != nil {
+		return c.actions, err
+	}
+
+	err := walkIncluded(src, matcher, func(rel string, d fs.DirEntry) error {
+		c.kept[rel] = true
+		srcPath := filepath.Join(src, filepath.FromSlash(rel))
+
+		switch {
+		case d.IsDir():
+			return c.ensureDir(rel, srcPath)
+		case d.Type()&fs.ModeSymlink != 0:
+			return c.syncSymlink(rel, srcPath)
+		case d.Type().IsRegular():
+			return c.syncFile(rel, srcPath)
+		default:
+			// Sockets, devices and named pipes are not copied
+			return nil
+		}
+	})
+	if err != nil {
+		return c.actions, fmt.Errorf("failed to copy %q to %q: %w", src, dst, err)
+	}
+
+	if opts.Delete {
+		if err := c.deleteExtraneous(); err != nil {
+			return c.actions, fmt.Errorf("failed to delete extraneous files from %q: %w", dst, err)
+		}
+	}
+	return c.actions, nil
+}
+
+// checkOverlap fails if src and dst are the same directory or one contains the other. Copying
+// into the source would copy the destination into itself without end, and deleting from it
+// would delete the ignored files of the source.
+func checkOverlap(src, dst string) error {
+	realSrc, err := resolvePath(src)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source %q: %w", src, err)
+	}
+	realDst, err := resolvePath(dst)
+	if err != nil {
+		return fmt.Errorf("failed to resolve destination %q: %w", dst, err)
+	}
+	if containsPath(realSrc, realDst) || containsPath(realDst, realSrc) {
+		return fmt.Errorf("source %q and destination %q overlap", src, dst)
+	}
+	return nil
+}
+
+// resolvePath returns the absolute path of name with symbolic links resolved. Components that do
+// not exist yet, such as a destination that is still to be created, are kept as given.
+func resolvePath(name string) (string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+
+	missing := ""
+	for dir := abs; ; {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs, nil
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+		dir = parent
+	}
+}
+
+// containsPath reports whether path is dir or lies below it. Both must be clean absolute paths.
+func containsPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// treeCopier holds the state of a CopyTree run.
+type treeCopier struct {
+	dst     string
+	opts    CopyOptions
+	actions []CopyAction
+	// kept holds the relative paths that exist in the filtered source
+	kept map[string]bool
+	// planned holds the directories a dry run would have created
+	planned map[string]bool
+}
+
+// record appends an action and reports whether it should actually be carried out.
+func (c *treeCopier) record(kind CopyActionKind, rel string) bool {
+	c.actions = append(c.actions, CopyAction{Kind: kind, Path: rel})
+	return !c.opts.DryRun
+}
+
+// dstPath returns the destination path for a relative path.
+func (c *treeCopier) dstPath(rel string) string {
+	return filepath.Join(c.dst, filepath.FromSlash(rel))
+}
+
+// lstat returns the FileInfo of rel in the destination. In a dry run, nothing exists yet below a
+// directory the run would have created, whatever the destination holds there now.
+func (c *treeCopier) lstat(rel string) (fs.FileInfo, error) {
+	if c.opts.DryRun && rel != "." {
+		for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+			if c.planned[dir] {
+				return nil, &fs.PathError{Op: "lstat", Path: c.dstPath(rel), Err: fs.ErrNotExist}
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+	return os.Lstat(c.dstPath(rel))
+}
+
+// remove deletes whatever is at rel in the destination.
+func (c *treeCopier) remove(rel string) error {
+	if c.record(ActionDelete, rel) {
+		return os.RemoveAll(c.dstPath(rel))
+	}
+	return nil
+}
+
+// ensureDir makes sure rel is a directory in the destination with the mode of srcPath.
+func (c *treeCopier) ensureDir(rel, srcPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.lstat(rel)
+	switch {
+	case err == nil && existing.IsDir():
+		return nil
+	case err == nil:
+		if err := c.remove(rel); err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if c.record(ActionMkdir, rel) {
+		return os.MkdirAll(c.dstPath(rel), info.Mode().Perm())
+	}
+	c.planned[rel] = true
+	return nil
+}
+
+// syncSymlink recreates the symlink at srcPath unless an identical one exists.
+func (c *treeCopier) syncSymlink(rel, srcPath string) error {
+	target, err := os.Readlink(srcPath)
+	if err != nil {
+		return err
+	}
+
+	dstPath := c.dstPath(rel)
+	if existing, err := c.lstat(rel); err == nil {
+		if existing.Mode()&fs.ModeSymlink != 0 {
+			if current, err := os.Readlink(dstPath); err == nil && current == target {
+				return nil
+			}
+		}
+		if err := c.remove(rel); err != nil {
+			return err
+		}
+	}
+
+	if c.record(ActionSymlink, rel) {
+		return os.Symlink(target, dstPath)
+	}
+	return nil
+}
+
+// syncFile copies the file at srcPath unless the destination is up to date.
+func (c *treeCopier) syncFile(rel, srcPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	dstPath := c.dstPath(rel)
+	if existing, err := c.lstat(rel); err == nil {
+		if existing.Mode().IsRegular() {
+			unchanged, err := c.unchanged(srcPath, dstPath, info, existing)
+			if err != nil || unchanged {
+				return err
+			}
+		} else if err := c.remove(rel); err != nil {
+			return err
+		}
+	}
+
+	if !c.record(ActionCopy, rel) {
+		return nil
+	}
+	return copyRegularFile(srcPath, dstPath, info)
+}
+
+// unchanged reports whether the destination file matches the source under the compare mode.
+func (c *treeCopier) unchanged(srcPath, dstPath string, srcInfo, dstInfo fs.FileInfo) (bool, error) {
+	switch c.opts.Compare {
+	case CompareSizeModTime:
+		return srcInfo.Size() == dstInfo.Size() && srcInfo.ModTime().Equal(dstInfo.ModTime()), nil
+	case CompareHash:
+		if srcInfo.Size() != dstInfo.Size() {
+			return false, nil
+		}
+		srcSum, err := fileDigest(srcPath)
+		if err != nil {
+			return false, err
+		}
+		dstSum, err := fileDigest(dstPath)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(srcSum, dstSum), nil
+	default:
+		return false, nil
+	}
+}
+
+// deleteExtraneous removes destination paths that are not part of the filtered source.
+func (c *treeCopier) deleteExtraneous() error {
+	if _, err := os.Stat(c.dst); errors.Is(err, fs.ErrNotExist) && c.opts.DryRun {
+		return nil
+	}
+
+	var extraneous []string
+	err := walkTree(c.dst, func(rel string, d fs.DirEntry) error {
+		if c.kept[rel] {
+			return nil
+		}
+		extraneous = append(extraneous, rel)
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(extraneous)
+	for _, rel := range extraneous {
+		if err := c.remove(rel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRegularFile copies src to dst through a temporary file in the destination directory, so
+// dst is never left partially written, and applies the source mode and modification time.
+func copyRegularFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+strings.TrimPrefix(filepath.Base(dst), ".")+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// fileDigest returns the SHA-256 digest of a file's contents.
+func fileDigest(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
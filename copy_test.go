@@ -0,0 +1,272 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCopyTree(t *testing.T) {
+	src := createArchiveTree(t)
+	matcher := archiveMatcher(t)
+	dst := filepath.Join(t.TempDir(), "out")
+
+	actions, err := CopyTree(src, dst, matcher, CopyOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []CopyAction{
+		{ActionMkdir, "."},
+		{ActionCopy, "README.md"},
+		{ActionMkdir, "bin"},
+		{ActionCopy, "bin/run.sh"},
+		{ActionSymlink, "link.md"},
+		{ActionMkdir, "src"},
+		{ActionCopy, "src/main.go"},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+
+	for _, name := range []string{"build", "src/debug.log", ".git"} {
+		if _, err := os.Lstat(filepath.Join(dst, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be excluded, got %v", name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dst, "src", "main.go"))
+	if err != nil || string(content) != "src/main.go" {
+		t.Errorf("Expected copied content %q, got %q (%v)", "src/main.go", content, err)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "bin", "run.sh"))
+	if err != nil {
+		t.Fatalf("Failed to stat copied file: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+
+	target, err := os.Readlink(filepath.Join(dst, "link.md"))
+	if err != nil || target != "README.md" {
+		t.Errorf("Expected symlink to README.md, got %q (%v)", target, err)
+	}
+}
+
+func TestCopyTreeIncremental(t *testing.T) {
+	src := createArchiveTree(t)
+	matcher := archiveMatcher(t)
+	dst := t.TempDir()
+
+	if _, err := CopyTree(src, dst, matcher, CopyOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		compare CompareMode
+		count   int
+	}{
+		{"size and mtime", CompareSizeModTime, 0},
+		{"hash", CompareHash, 0},
+		{"none", CompareNone, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actions, err := CopyTree(src, dst, matcher, CopyOptions{Compare: test.compare})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(actions) != test.count {
+				t.Errorf("Expected %d actions, got %v", test.count, actions)
+			}
+		})
+	}
+
+	// Same size, different content and modification time
+	srcFile := filepath.Join(src, "src", "main.go")
+	if err := os.WriteFile(srcFile, []byte("src/MAIN.go"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(srcFile, future, future); err != nil {
+		t.Fatalf("Failed to change times: %v", err)
+	}
+
+	for _, compare := range []CompareMode{CompareHash, CompareSizeModTime} {
+		actions, err := CopyTree(src, dst, matcher, CopyOptions{Compare: compare, DryRun: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []CopyAction{{ActionCopy, "src/main.go"}}
+		if !reflect.DeepEqual(actions, expected) {
+			t.Errorf("Expected actions %v for mode %d, got %v", expected, compare, actions)
+		}
+	}
+}
+
+func TestCopyTreeDelete(t *testing.T) {
+	src := createTree(t, "a.txt", "keep/b.txt")
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	dst := createTree(t, "a.txt", "extra.txt", "old/c.txt", "keep/stale.log", "keep/b.txt/")
+
+	actions, err := CopyTree(src, dst, matcher, CopyOptions{Compare: CompareHash, Delete: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []CopyAction{
+		{ActionDelete, "keep/b.txt"},
+		{ActionCopy, "keep/b.txt"},
+		{ActionDelete, "extra.txt"},
+		{ActionDelete, "keep/stale.log"},
+		{ActionDelete, "old"},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "extra.txt")); err != nil {
+		t.Errorf("Expected dry run to leave extra.txt in place, got %v", err)
+	}
+
+	actions, err = CopyTree(src, dst, matcher, CopyOptions{Compare: CompareHash, Delete: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+	for _, name := range []string{"extra.txt", "old", "keep/stale.log"} {
+		if _, err := os.Lstat(filepath.Join(dst, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted, got %v", name, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dst, "keep", "b.txt")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("Expected keep/b.txt to be a regular file, got %v", err)
+	}
+}
+
+func TestCopyTreeDryRun(t *testing.T) {
+	src := createTree(t, "a.txt", "dir/b.txt")
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), "out")
+
+	actions, err := CopyTree(src, dst, matcher, CopyOptions{DryRun: true, Delete: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(actions) != 4 {
+		t.Errorf("Expected 4 actions, got %v", actions)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("Expected dry run not to create the destination, got %v", err)
+	}
+}
+
+func TestCopyTreeErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	if _, err := CopyTree("", t.TempDir(), matcher, CopyOptions{}); err == nil {
+		t.Error("Expected error for empty source")
+	}
+	if _, err := CopyTree(t.TempDir(), t.TempDir(), nil, CopyOptions{}); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+	if _, err := CopyTree(filepath.Join(t.TempDir(), "missing"), t.TempDir(), matcher, CopyOptions{}); err == nil {
+		t.Error("Expected error for missing source")
+	}
+}
+
+func TestCopyTreeOverlap(t *testing.T) {
+	src := createTree(t, "a.txt", "secret.log", "sub/b.txt")
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(src, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		dst  string
+	}{
+		{"same directory", src, src},
+		{"same directory with trailing slash", src, src + string(filepath.Separator)},
+		{"destination inside source", src, filepath.Join(src, "out")},
+		{"missing destination inside source", src, filepath.Join(src, "out", "deeper")},
+		{"source inside destination", filepath.Join(src, "sub"), src},
+		{"destination through a symlink", src, filepath.Join(link, "out")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actions, err := CopyTree(test.src, test.dst, matcher, CopyOptions{Delete: true})
+			if err == nil {
+				t.Fatalf("Expected error for overlapping paths, got actions %v", actions)
+			}
+			if len(actions) != 0 {
+				t.Errorf("Expected no actions, got %v", actions)
+			}
+		})
+	}
+
+	// Nothing in the source was touched
+	for _, name := range []string{"a.txt", "secret.log", "sub/b.txt"} {
+		if _, err := os.Stat(filepath.Join(src, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "out")); !os.IsNotExist(err) {
+		t.Errorf("Expected no out directory in the source, got %v", err)
+	}
+
+	// A sibling whose name extends the source does not overlap
+	if _, err := CopyTree(src, src+"-copy", matcher, CopyOptions{}); err != nil {
+		t.Errorf("Unexpected error for a sibling destination: %v", err)
+	}
+}
+
+func TestCopyTreeDryRunReplacesFileWithDirectory(t *testing.T) {
+	src := createTree(t, "a/b/f.txt", "c.txt")
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	dst := createTree(t, "a", "c.txt/")
+
+	expected := []CopyAction{
+		{ActionDelete, "a"},
+		{ActionMkdir, "a"},
+		{ActionMkdir, "a/b"},
+		{ActionCopy, "a/b/f.txt"},
+		{ActionDelete, "c.txt"},
+		{ActionCopy, "c.txt"},
+	}
+	for _, dryRun := range []bool{true, false} {
+		actions, err := CopyTree(src, dst, matcher, CopyOptions{Delete: true, DryRun: dryRun})
+		if err != nil {
+			t.Fatalf("Unexpected error with DryRun %v: %v", dryRun, err)
+		}
+		if !reflect.DeepEqual(actions, expected) {
+			t.Errorf("Expected actions %v with DryRun %v, got %v", expected, dryRun, actions)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a", "b", "f.txt")); err != nil {
+		t.Errorf("Expected a/b/f.txt to be copied, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+)
+
+// Rule identifies a pattern of a PatternMatcher.
+type Rule struct {
+	// Pattern is the pattern as written, including any leading "!" or trailing "/".
+	Pattern string
+	// Line is the 1-based position of the pattern in the list it was parsed from.
+	Line int
+}
+
+// Explanation describes how a path was classified.
+type Explanation struct {
+	Ignored bool
+	// Rule is the decisive pattern: the last one that matched the path. It is nil if no pattern
+	// matched.
+	Rule *Rule
+	// Tracked is set if the path is not ignored because it is tracked; see WithTracked.
+	Tracked bool
+}
+
+// Explain is like MatchesPath, but also reports which pattern decided the outcome, similar to
+// `git check-ignore -v`.
+func (p *PatternMatcher) Explain(file string, isDir bool) (Explanation, error) {
+	file, ok, err := p.normalizePath(file)
+	if err != nil || !ok {
+		return Explanation{}, err
+	}
+
+	var info pathInfo
+	info.reset(file, isDir)
+	ignored, decisive, err := p.evaluate(&info, nil)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation := Explanation{Ignored: ignored, Tracked: p.isTracked(file)}
+	if decisive >= 0 {
+		rule := p.rule(decisive)
+		explanation.Rule = &rule
+	}
+	return explanation, nil
+}
+
+// rule returns the Rule for the pattern at index i.
+func (p *PatternMatcher) rule(i int) Rule {
+	return Rule{Pattern: p.ignorePatterns[i].text, Line: p.ignorePatterns[i].line}
+}
+
+// RuleCoverage reports how a single pattern was exercised.
+type RuleCoverage struct {
+	Rule
+	// Matched is the number of paths the pattern matched.
+	Matched int
+	// Decisive is the number of paths for which the pattern was the last match and therefore
+	// decided the outcome.
+	Decisive int
+}
+
+// CoverageReport summarizes which patterns took part in classifying a set of paths.
+type CoverageReport struct {
+	// Paths is the number of paths evaluated.
+	Paths int
+	// Rules holds one entry per pattern, in the order the patterns were given.
+	Rules []RuleCoverage
+}
+
+// Unmatched returns the patterns that matched no path.
+func (r CoverageReport) Unmatched() []RuleCoverage {
+	var unmatched []RuleCoverage
+	for _, rule := range r.Rules {
+		if rule.Matched == 0 {
+			unmatched = append(unmatched, rule)
+		}
+	}
+	return unmatched
+}
+
+// Coverage wraps a PatternMatcher and records, for every path it evaluates, which patterns
+// matched and which one was decisive. It is safe for concurrent use.
+type Coverage struct {
+	matcher *PatternMatcher
+
+	mu       sync.Mutex
+	paths    int
+	matched  []int
+	decisive []int
+}
+
+// NewCoverage returns a Coverage recorder for matcher.
+func NewCoverage(matcher *PatternMatcher) *Coverage {
+	return &Coverage{
+		matcher:  matcher,
+		matched:  make([]int, len(matcher.ignorePatterns)),
+		decisive: make([]int, len(matcher.ignorePatterns)),
+	}
+}
+
+// Matches is PatternMatcher.Matches that records coverage.
+func (c *Coverage) Matches(file string) (bool, error) {
+	return c.MatchesPath(file, true)
+}
+
+// MatchesPath is PatternMatcher.MatchesPath that records coverage. Paths that denote the root of
+// the tree are not counted.
+func (c *Coverage) MatchesPath(file string, isDir bool) (bool, error) {
+	file, ok, err := c.matcher.normalizePath(file)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	var matches []int
+	var info pathInfo
+	info.reset(file, isDir)
+	ignored, decisive, err := c.matcher.evaluate(&info, func(index int) {
+		matches = append(matches, index)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths++
+	for _, index := range matches {
+		c.matched[index]++
+	}
+	if decisive >= 0 {
+		c.decisive[decisive]++
+	}
+	return ignored, nil
+}
+
+// Report returns the coverage recorded so far.
+func (c *Coverage) Report() CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := CoverageReport{
+		Paths: c.paths,
+		Rules: make([]RuleCoverage, len(c.matched)),
+	}
+	for i := range c.matched {
+		report.Rules[i] = RuleCoverage{
+			Rule:     c.matcher.rule(i),
+			Matched:  c.matched[i],
+			Decisive: c.decisive[i],
+		}
+	}
+	return report
+}
+
+// CoverPaths evaluates every path with Matches and returns the resulting coverage.
+func CoverPaths(matcher *PatternMatcher, paths []string) (CoverageReport, error) {
+	if matcher == nil {
+		return CoverageReport{}, errors.New("matcher cannot be nil")
+	}
+
+	coverage := NewCoverage(matcher)
+	for _, path := range paths {
+		if _, err := coverage.Matches(path); err != nil {
+			return CoverageReport{}, err
+		}
+	}
+	return coverage.Report(), nil
+}
+
+// CoverTree evaluates every file and directory under root, except the .git directory, and returns
+// the resulting coverage. Directories are walked in full, including ignored ones, so that patterns
+// for paths inside them are exercised too.
+func CoverTree(matcher *PatternMatcher, root string) (CoverageReport, error) {
+	if matcher == nil {
+		return CoverageReport{}, errors.New("matcher cannot be nil")
+	}
+
+	coverage := NewCoverage(matcher)
+	err := walkTree(root, func(rel string, d fs.DirEntry) error {
+		_, err := coverage.MatchesPath(rel, d.IsDir())
+		return err
+	})
+	if err != nil {
+		return CoverageReport{}, err
+	}
+	return coverage.Report(), nil
+}
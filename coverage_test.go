@@ -0,0 +1,136 @@
+package dotignore
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"# logs", "*.log", "  !important.log", "build/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		isDir    bool
+		ignored  bool
+		pattern  string
+		line     int
+		hasMatch bool
+	}{
+		{"app.log", false, true, "*.log", 2, true},
+		{"important.log", false, false, "!important.log", 3, true},
+		{"build", true, true, "build/", 4, true},
+		{"build", false, false, "", 0, false},
+		{"src/main.go", false, false, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			explanation, err := matcher.Explain(tt.file, tt.isDir)
+			if err != nil {
+				t.Fatalf("Explain failed: %v", err)
+			}
+			if explanation.Ignored != tt.ignored {
+				t.Errorf("Expected ignored=%v, got %v", tt.ignored, explanation.Ignored)
+			}
+			if (explanation.Rule != nil) != tt.hasMatch {
+				t.Fatalf("Expected decisive rule presence %v, got %+v", tt.hasMatch, explanation.Rule)
+			}
+			if tt.hasMatch && (explanation.Rule.Pattern != tt.pattern || explanation.Rule.Line != tt.line) {
+				t.Errorf("Expected decisive rule %q on line %d, got %+v", tt.pattern, tt.line, *explanation.Rule)
+			}
+		})
+	}
+}
+
+func TestCoverPaths(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log", "!important.log", "build/", "*.bak", "**/*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	report, err := CoverPaths(matcher, []string{"app.log", "important.log", "build/app.js", "src/main.go", ""})
+	if err != nil {
+		t.Fatalf("CoverPaths failed: %v", err)
+	}
+
+	if report.Paths != 4 {
+		t.Errorf("Expected 4 evaluated paths, got %d", report.Paths)
+	}
+
+	expected := []struct {
+		matched  int
+		decisive int
+	}{
+		{2, 0}, // *.log, always overridden by a later rule
+		{1, 0}, // !important.log, overridden by **/*.log
+		{1, 1}, // build/
+		{0, 0}, // *.bak
+		{2, 2}, // **/*.log
+	}
+	for i, want := range expected {
+		got := report.Rules[i]
+		if got.Matched != want.matched || got.Decisive != want.decisive {
+			t.Errorf("Rule %q: expected matched=%d decisive=%d, got matched=%d decisive=%d",
+				got.Pattern, want.matched, want.decisive, got.Matched, got.Decisive)
+		}
+	}
+
+	unmatched := report.Unmatched()
+	if len(unmatched) != 1 || unmatched[0].Pattern != "*.bak" || unmatched[0].Line != 4 {
+		t.Errorf("Expected *.bak on line 4 to be the only unmatched rule, got %+v", unmatched)
+	}
+}
+
+func TestCoverTree(t *testing.T) {
+	root := createTree(t, "app.log", "build/app.js", "src/main.go", ".git/HEAD", "node_modules/x/index.js")
+	matcher, err := NewPatternMatcher([]string{"*.log", "build/", "dist/", "HEAD", "node_modules/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	report, err := CoverTree(matcher, root)
+	if err != nil {
+		t.Fatalf("CoverTree failed: %v", err)
+	}
+
+	var unmatched []string
+	for _, rule := range report.Unmatched() {
+		unmatched = append(unmatched, rule.Pattern)
+	}
+	if len(unmatched) != 2 || unmatched[0] != "dist/" || unmatched[1] != "HEAD" {
+		t.Errorf("Expected dist/ and HEAD to be unmatched, got %v", unmatched)
+	}
+
+	// Directories are evaluated and walked even when ignored
+	if rule := report.Rules[4]; rule.Matched != 3 {
+		t.Errorf("Expected node_modules/ to match the directory and everything below it, got %d", rule.Matched)
+	}
+}
+
+// TestCoverageConcurrent is meant to be run with -race.
+func TestCoverageConcurrent(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	coverage := NewCoverage(matcher)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				coverage.Matches("app.log")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if report := coverage.Report(); report.Paths != 400 || report.Rules[0].Matched != 400 {
+		t.Errorf("Expected 400 paths and matches, got %+v", report)
+	}
+}
@@ -0,0 +1,101 @@
+package dotignore
+
+// Cursor classifies a sequence of paths while caching the decisions made for their ancestor
+// directories. It is intended for paths that arrive in sorted order, such as the output of a
+// directory walk or `git ls-files`: consecutive paths share most of their ancestors, so each
+// directory is evaluated once, and everything below an ignored directory is classified without
+// evaluating any pattern.
+//
+// Like git, a Cursor treats every path below an ignored directory as ignored, even when a later
+// negation pattern matches the path itself; Matches on the PatternMatcher evaluates each path on
+// its own. Paths may be given in any order, but unsorted input loses most of the benefit of the
+// cache. A Cursor is not safe for concurrent use; create one per goroutine.
+type Cursor struct {
+	matcher *PatternMatcher
+	info    pathInfo
+	scratch pathInfo
+
+	// dirs holds the decisions for the ancestor directories of the last path, outermost first
+	dirs []dirDecision
+	// last holds the decision for the last path itself, which may turn out to be a directory
+	last dirDecision
+}
+
+// dirDecision records whether a path was ignored.
+type dirDecision struct {
+	path    string
+	ignored bool
+}
+
+// NewCursor returns a Cursor that classifies paths against the matcher's rules.
+func (p *PatternMatcher) NewCursor() *Cursor {
+	return &Cursor{matcher: p}
+}
+
+// Reset discards all cached directory decisions.
+func (c *Cursor) Reset() {
+	c.dirs = c.dirs[:0]
+	c.last = dirDecision{}
+}
+
+// Matches reports whether path should be ignored, either because it matches the rules or because
+// one of its ancestor directories does.
+func (c *Cursor) Matches(path string) (bool, error) {
+	file, ok, err := c.matcher.normalizePath(path)
+	if err != nil || !ok {
+		return false, err
+	}
+	c.info.reset(file, true)
+
+	// Keep the cached ancestors that are still ancestors of this path
+	depth := c.info.numComponents() - 1
+	keep := 0
+	for keep < len(c.dirs) && keep < depth && c.dirs[keep].path == c.ancestor(keep) {
+		keep++
+	}
+	c.dirs = c.dirs[:keep]
+
+	for i := keep; i < depth; i++ {
+		dir := c.ancestor(i)
+		decision := dirDecision{path: dir}
+		switch {
+		case i > 0 && c.dirs[i-1].ignored:
+			decision.ignored = true
+		case dir == "":
+			// The empty component before the first slash of an absolute path is not a directory
+		case c.last.path == dir:
+			decision.ignored = c.last.ignored
+		default:
+			ignored, err := c.evaluate(dir)
+			if err != nil {
+				return false, err
+			}
+			decision.ignored = ignored
+		}
+		c.dirs = append(c.dirs, decision)
+	}
+
+	c.last = dirDecision{path: file}
+	if depth > 0 && c.dirs[depth-1].ignored {
+		c.last.ignored = true
+		return true, nil
+	}
+
+	ignored, err := c.matcher.matchesInternal(&c.info)
+	if err != nil {
+		return false, err
+	}
+	c.last.ignored = ignored
+	return ignored, nil
+}
+
+// ancestor returns the i-th ancestor directory of the current path, outermost first.
+func (c *Cursor) ancestor(i int) string {
+	return c.info.path[:c.info.starts[i+1]-1]
+}
+
+// evaluate matches a directory path against the rules using the scratch buffer.
+func (c *Cursor) evaluate(dir string) (bool, error) {
+	c.scratch.reset(dir, true)
+	return c.matcher.matchesInternal(&c.scratch)
+}
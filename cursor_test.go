@@ -0,0 +1,168 @@
+package dotignore
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ignoredWithAncestors is the reference for Cursor: a path is ignored if it or any of its ancestor
+// directories matches.
+func ignoredWithAncestors(t *testing.T, matcher *PatternMatcher, file string) bool {
+	t.Helper()
+	for dir := file; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		matched, err := matcher.Matches(dir)
+		if err != nil {
+			t.Fatalf("Matches failed: %v", err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCursor(t *testing.T) {
+	patterns := []string{"*.log", "!important.log", "build/", "!build/keep.txt", "**/node_modules/", "docs/*.tmp"}
+	matcher, err := NewPatternMatcher(patterns)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	paths := []string{
+		"README.md",
+		"app.log",
+		"build",
+		"build/app.js",
+		"build/keep.txt",
+		"build/sub/deep.js",
+		"docs/a.tmp",
+		"docs/guide.md",
+		"important.log",
+		"logs.log/inner.txt",
+		"src/lib/node_modules/pkg/index.js",
+		"src/lib/util.go",
+		"src/main.go",
+	}
+	sort.Strings(paths)
+
+	cursor := matcher.NewCursor()
+	for _, file := range paths {
+		got, err := cursor.Matches(file)
+		if err != nil {
+			t.Fatalf("Cursor.Matches failed: %v", err)
+		}
+		if want := ignoredWithAncestors(t, matcher, file); got != want {
+			t.Errorf("Path %q: expected %v, got %v", file, want, got)
+		}
+	}
+}
+
+func TestCursorIgnoredDirectoryCannotBeReincluded(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"temp/", "!temp/keep.txt"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	cursor := matcher.NewCursor()
+	ignored, err := cursor.Matches("temp/keep.txt")
+	if err != nil {
+		t.Fatalf("Cursor.Matches failed: %v", err)
+	}
+	if !ignored {
+		t.Error("Expected temp/keep.txt to be ignored because temp/ is ignored")
+	}
+}
+
+func TestCursorUnsortedInput(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"vendor/", "*.tmp", "!keep.tmp"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	paths := []string{
+		"src/a.go", "vendor/x/y.go", "src/keep.tmp", "a/b/c/d.tmp", "vendor", "a/b/e.go",
+		"/abs/path/file.go", "./src/b.tmp", "", ".", "src\\win.tmp",
+	}
+
+	cursor := matcher.NewCursor()
+	for _, file := range paths {
+		got, err := cursor.Matches(file)
+		if err != nil {
+			t.Fatalf("Cursor.Matches failed: %v", err)
+		}
+		normalized, ok := normalizeMatchPath(file)
+		want := ok && ignoredWithAncestors(t, matcher, normalized)
+		if got != want {
+			t.Errorf("Path %q: expected %v, got %v", file, want, got)
+		}
+	}
+}
+
+// countingTracked is a TrackedSet that tracks nothing and counts how often it is asked, which is
+// once for every path evaluated against the patterns.
+type countingTracked struct {
+	calls int
+}
+
+func (c *countingTracked) Tracked(string) bool {
+	c.calls++
+	return false
+}
+
+func TestCursorReusesDirectoryDecisions(t *testing.T) {
+	evaluations := &countingTracked{}
+	matcher, err := NewPatternMatcher([]string{"node_modules/", "*.log"}, WithTracked(evaluations))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var paths []string
+	for _, pkg := range []string{"a", "b", "c"} {
+		for _, file := range []string{"index.js", "package.json", "lib/util.js"} {
+			paths = append(paths, "node_modules/"+pkg+"/"+file)
+		}
+	}
+	paths = append(paths, "src/app/main.go", "src/app/main_test.go", "src/app/debug.log")
+
+	cursor := matcher.NewCursor()
+	for _, file := range paths {
+		if _, err := cursor.Matches(file); err != nil {
+			t.Fatalf("Cursor.Matches failed: %v", err)
+		}
+	}
+
+	// node_modules, src and src/app are evaluated once each, plus the three files under src/app
+	if evaluations.calls != 6 {
+		t.Errorf("Expected 6 evaluations, got %d", evaluations.calls)
+	}
+
+	cursor.Reset()
+	if len(cursor.dirs) != 0 {
+		t.Error("Expected Reset to clear cached directories")
+	}
+}
+
+func BenchmarkCursor(b *testing.B) {
+	matcher, err := NewPatternMatcher([]string{"node_modules/", "*.log", "build/", "**/*.tmp"})
+	if err != nil {
+		b.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	var paths []string
+	for _, dir := range []string{"node_modules/react/lib", "node_modules/vue/dist", "src/components/ui", "src/utils"} {
+		for _, file := range []string{"a.js", "b.js", "c.js", "d.log", "e.tmp"} {
+			paths = append(paths, strings.Join([]string{dir, file}, "/"))
+		}
+	}
+	sort.Strings(paths)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cursor := matcher.NewCursor()
+		for _, file := range paths {
+			_, _ = cursor.Matches(file)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+// Reasons reported by lenient parsing for lines that are accepted but probably do not mean what
+// their author intended. They are reported with SeverityWarning and can be tested with errors.Is.
+var (
+	// ErrLeadingWhitespace is reported for patterns with leading whitespace, which is removed.
+	ErrLeadingWhitespace = errors.New("leading whitespace is removed")
+	// ErrTrailingWhitespace is reported for patterns with trailing whitespace, which is removed.
+	ErrTrailingWhitespace = errors.New("trailing whitespace is removed")
+	// ErrBackslashSeparator is reported for patterns containing backslashes, which are treated as
+	// path separators rather than escapes.
+	ErrBackslashSeparator = errors.New("backslash is treated as a path separator")
+	// ErrUnclosedBracket is reported for a "[" without a closing "]", which is matched literally.
+	ErrUnclosedBracket = errors.New("unclosed '[' is matched literally")
+	// ErrInvalidUTF8 is reported for patterns that are not valid UTF-8, which only MatchesBytes
+	// and Status apply.
+	ErrInvalidUTF8 = errors.New("pattern is not valid UTF-8 and only matches byte paths")
+)
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError marks a line that was skipped because it could not be parsed.
+	SeverityError Severity = iota + 1
+	// SeverityWarning marks a line that was accepted but may not behave as intended.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Diagnostic describes a problem with one line of an ignore file found by lenient parsing.
+type Diagnostic struct {
+	Severity Severity
+	// File is the ignore file the line was read from, if any.
+	File string
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based byte column the problem starts at.
+	Column int
+	// Pattern is the line as it was given.
+	Pattern string
+	// Reason describes the problem. For errors it wraps one of the sentinel parse errors; for
+	// warnings it is one of the warning reasons.
+	Reason error
+}
+
+func (d Diagnostic) String() string {
+	location := fmt.Sprintf("%d:%d", d.Line, d.Column)
+	if d.File != "" {
+		location = d.File + ":" + location
+	}
+	return fmt.Sprintf("%s: %s: %v", location, d.Severity, d.Reason)
+}
+
+// NewPatternMatcherLenient is like NewPatternMatcher, but instead of failing on the first invalid
+// line it skips every invalid line and keeps going. It always returns a usable matcher, together
+// with a diagnostic for every skipped line and a warning for every line that was accepted but
+// changed during parsing. Diagnostics are ordered by line.
+func NewPatternMatcherLenient(patterns []string, opts ...Option) (*PatternMatcher, []Diagnostic) {
+	return newPatternMatcherLenient(patterns, nil, buildOptions(opts))
+}
+
+// NewPatternMatcherFromFileLenient is the lenient counterpart of NewPatternMatcherFromFile.
+// It only returns an error if the file cannot be read.
+func NewPatternMatcherFromFileLenient(filePath string, opts ...Option) (*PatternMatcher, []Diagnostic, error) {
+	if filePath == "" {
+		return nil, nil, errors.New("file path cannot be empty")
+	}
+
+	o := fileOptions(filePath, opts)
+	patterns, readDiagnostics, err := readPatternFileLenient(filePath, o.limits.MaxPatternLength)
+	if err != nil {
+		return nil, nil, err
+	}
+	matcher, diagnostics := newPatternMatcherLenient(patterns, readDiagnostics, o)
+	return matcher, diagnostics, nil
+}
+
+// readPatternFileLenient is like readPatternFile, but skips the rest of a line once it is longer
+// than maxLength, leaving the line blank and reporting it with an error diagnostic.
+func readPatternFileLenient(filePath string, maxLength int) ([]string, []Diagnostic, error) {
+	fileReader, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file %q: %w", filePath, err)
+	}
+	defer fileReader.Close()
+
+	patterns, tooLong, err := internal.ReadLinesTruncate(fileReader, maxLength)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse patterns from file %q: %w", filePath, err)
+	}
+	var diagnostics []Diagnostic
+	for _, lineErr := range tooLong {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Line:     lineErr.Line,
+			Column:   maxLength + 1,
+			Reason:   fmt.Errorf("%w: limit is %d", ErrPatternTooLong, maxLength),
+		})
+	}
+	return patterns, diagnostics, nil
+}
+
+// newPatternMatcherLenient builds a PatternMatcher with already resolved options, skipping
+// invalid lines. readDiagnostics holds the problems found while reading the lines, if any.
+func newPatternMatcherLenient(patterns []string, readDiagnostics []Diagnostic, opts options) (*PatternMatcher, []Diagnostic) {
+	patterns, limitDiagnostics := applyLimits(patterns, opts.limits)
+	limitDiagnostics = append(readDiagnostics, limitDiagnostics...)
+	ignorePatterns, diagnostics := buildIgnorePatternsLenient(normalizePatterns(patterns, opts))
+	if len(limitDiagnostics) > 0 {
+		diagnostics = append(limitDiagnostics, diagnostics...)
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			return diagnostics[i].Line < diagnostics[j].Line
+		})
+	}
+	for i := range diagnostics {
+		diagnostics[i].File = opts.sourceFile
+	}
+	return &PatternMatcher{
+		ignorePatterns: ignorePatterns,
+		options:        opts,
+		cache:          newResultCache(opts.cacheSize),
+	}, diagnostics
+}
+
+// buildIgnorePatternsLenient parses every line, collecting diagnostics instead of stopping at the
+// first invalid one.
+func buildIgnorePatternsLenient(patterns []string) ([]ignorePattern, []Diagnostic) {
+	var ignorePatterns []ignorePattern
+	var diagnostics []Diagnostic
+
+	for i, line := range patterns {
+		pattern, ok, err := parsePattern(line, i+1)
+		if err != nil {
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityError,
+					Line:     parseErr.Line,
+					Column:   parseErr.Column,
+					Pattern:  parseErr.Pattern,
+					Reason:   parseErr.Reason,
+				})
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		ignorePatterns = append(ignorePatterns, pattern)
+		diagnostics = append(diagnostics, patternWarnings(line, i+1)...)
+	}
+
+	return ignorePatterns, diagnostics
+}
+
+// patternWarnings reports the changes parsePattern silently makes to a valid line.
+func patternWarnings(line string, lineNumber int) []Diagnostic {
+	var warnings []Diagnostic
+	warn := func(column int, reason error) {
+		warnings = append(warnings, Diagnostic{
+			Severity: SeverityWarning,
+			Line:     lineNumber,
+			Column:   column,
+			Pattern:  line,
+			Reason:   reason,
+		})
+	}
+
+	trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+	start := len(line) - len(trimmed)
+	if start > 0 {
+		warn(1, ErrLeadingWhitespace)
+	}
+
+	body := strings.TrimRightFunc(line, unicode.IsSpace)
+	if len(body) < len(line) {
+		warn(len(body)+1, ErrTrailingWhitespace)
+	}
+
+	if !utf8.ValidString(body) {
+		warn(invalidUTF8Column(body), ErrInvalidUTF8)
+	}
+
+	if index := strings.IndexByte(body[start:], '\\'); index >= 0 {
+		warn(start+index+1, ErrBackslashSeparator)
+	}
+
+	// Backslashes are path separators by the time brackets are parsed
+	slashed := strings.ReplaceAll(body, "\\", "/")
+	for index := start; index < len(slashed); index++ {
+		if slashed[index] == '[' && internal.ClassEnd(slashed, index) < 0 {
+			warn(index+1, ErrUnclosedBracket)
+			break
+		}
+	}
+
+	return warnings
+}
+
+// invalidUTF8Column returns the 1-based column of the first invalid UTF-8 byte in s.
+func invalidUTF8Column(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i + 1
+		}
+		i += size
+	}
+	return 0
+}
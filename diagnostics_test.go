@@ -0,0 +1,105 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewPatternMatcherLenient(t *testing.T) {
+	patterns := []string{
+		"*.log",
+		"!",
+		"  build/",
+		"dist/  ",
+		"/",
+		"src\\gen\\",
+		"file[]z-a]x",
+		"data[0-9",
+		"!important.log",
+	}
+
+	matcher, diagnostics := NewPatternMatcherLenient(patterns)
+	if matcher == nil {
+		t.Fatal("Expected a usable matcher")
+	}
+
+	expected := []struct {
+		severity Severity
+		line     int
+		column   int
+		reason   error
+	}{
+		{SeverityError, 2, 1, ErrLoneNegation},
+		{SeverityWarning, 3, 1, ErrLeadingWhitespace},
+		{SeverityWarning, 4, 6, ErrTrailingWhitespace},
+		{SeverityError, 5, 1, ErrEmptyPattern},
+		{SeverityWarning, 6, 4, ErrBackslashSeparator},
+		{SeverityError, 7, 5, ErrBadBracket},
+		{SeverityWarning, 8, 5, ErrUnclosedBracket},
+	}
+
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %d: %v", len(expected), len(diagnostics), diagnostics)
+	}
+	for i, want := range expected {
+		got := diagnostics[i]
+		if got.Severity != want.severity || got.Line != want.line || got.Column != want.column || !errors.Is(got.Reason, want.reason) {
+			t.Errorf("Diagnostic %d: expected %v at %d:%d (%v), got %v", i, want.severity, want.line, want.column, want.reason, got)
+		}
+		if got.Pattern != patterns[got.Line-1] {
+			t.Errorf("Diagnostic %d: expected pattern %q, got %q", i, patterns[got.Line-1], got.Pattern)
+		}
+	}
+
+	// Valid lines around the invalid ones still take effect
+	tests := []struct {
+		file     string
+		expected bool
+	}{
+		{"app.log", true},
+		{"important.log", false},
+		{"build/app.js", true},
+		{"dist/app.js", true},
+		{"src/gen/code.go", true},
+		{"src/main.go", false},
+	}
+	for _, tt := range tests {
+		if result, _ := matcher.Matches(tt.file); result != tt.expected {
+			t.Errorf("File %q: expected %v, got %v", tt.file, tt.expected, result)
+		}
+	}
+}
+
+func TestNewPatternMatcherLenientNoDiagnostics(t *testing.T) {
+	_, diagnostics := NewPatternMatcherLenient([]string{"# comment", "", "*.log", "build/"})
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestNewPatternMatcherFromFileLenient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("*.log\n!\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	matcher, diagnostics, err := NewPatternMatcherFromFileLenient(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].File != path || diagnostics[0].Severity != SeverityError {
+		t.Fatalf("Expected one error diagnostic for %s, got %v", path, diagnostics)
+	}
+	if expected := path + ":2:1: error: single '!' is not allowed"; diagnostics[0].String() != expected {
+		t.Errorf("Expected %q, got %q", expected, diagnostics[0].String())
+	}
+	if matched, _ := matcher.Matches("app.log"); !matched {
+		t.Error("Expected app.log to be ignored")
+	}
+
+	if _, _, err := NewPatternMatcherFromFileLenient(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
@@ -0,0 +1,76 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// DiffResult lists the paths whose status differs between two rule sets.
+type DiffResult struct {
+	// NewlyIgnored holds paths ignored by the new rules but not by the old ones.
+	NewlyIgnored []string
+	// NewlyUnignored holds paths ignored by the old rules but not by the new ones.
+	NewlyUnignored []string
+}
+
+// Empty reports whether no path changed status.
+func (d DiffResult) Empty() bool {
+	return len(d.NewlyIgnored) == 0 && len(d.NewlyUnignored) == 0
+}
+
+// Diff evaluates paths against both matchers with Matches and reports the paths whose status
+// changed, in the order given.
+func Diff(oldMatcher, newMatcher *PatternMatcher, paths []string) (DiffResult, error) {
+	if oldMatcher == nil || newMatcher == nil {
+		return DiffResult{}, errors.New("matchers cannot be nil")
+	}
+
+	var result DiffResult
+	for _, path := range paths {
+		if err := result.add(oldMatcher, newMatcher, path, true); err != nil {
+			return DiffResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// DiffTree walks the files under root and reports, in lexical order, the files whose status
+// changed between the two matchers. Paths are relative to root and use forward slashes.
+// Directories are walked in full, whatever either rule set says about them, except for .git.
+func DiffTree(oldMatcher, newMatcher *PatternMatcher, root string) (DiffResult, error) {
+	if oldMatcher == nil || newMatcher == nil {
+		return DiffResult{}, errors.New("matchers cannot be nil")
+	}
+
+	var result DiffResult
+	err := walkTree(root, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		return result.add(oldMatcher, newMatcher, rel, false)
+	})
+	if err != nil {
+		return DiffResult{}, err
+	}
+	return result, nil
+}
+
+// add records path if its status differs between the two matchers.
+func (d *DiffResult) add(oldMatcher, newMatcher *PatternMatcher, path string, isDir bool) error {
+	wasIgnored, err := oldMatcher.MatchesPath(path, isDir)
+	if err != nil {
+		return err
+	}
+	isIgnored, err := newMatcher.MatchesPath(path, isDir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isIgnored && !wasIgnored:
+		d.NewlyIgnored = append(d.NewlyIgnored, path)
+	case wasIgnored && !isIgnored:
+		d.NewlyUnignored = append(d.NewlyUnignored, path)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// createTree creates the given files, with their names as content, below a new temporary
+// directory and returns its path. Names ending in a slash create empty directories.
+func createTree(t *testing.T, files ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return root
+}
+
+func TestDiff(t *testing.T) {
+	oldMatcher, err := NewPatternMatcher([]string{"*.log", "build/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	newMatcher, err := NewPatternMatcher([]string{"*.log", "!keep.log", "dist/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	paths := []string{"app.log", "keep.log", "build/app.js", "dist/app.js", "src/main.go"}
+	result, err := Diff(oldMatcher, newMatcher, paths)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(result.NewlyIgnored, []string{"dist/app.js"}) {
+		t.Errorf("Expected newly ignored [dist/app.js], got %v", result.NewlyIgnored)
+	}
+	if !reflect.DeepEqual(result.NewlyUnignored, []string{"keep.log", "build/app.js"}) {
+		t.Errorf("Expected newly un-ignored [keep.log build/app.js], got %v", result.NewlyUnignored)
+	}
+	if result.Empty() {
+		t.Error("Expected a non-empty result")
+	}
+
+	if _, err := Diff(nil, newMatcher, paths); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+}
+
+func TestDiffTree(t *testing.T) {
+	root := createTree(t,
+		"app.log", "build/app.js", "build/sub/lib.js", "dist/bundle.js", "src/main.go", "src/build",
+		".git/config", "empty/",
+	)
+
+	oldMatcher, err := NewPatternMatcher([]string{"build/", "*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	newMatcher, err := NewPatternMatcher([]string{"dist/", "*.log", "config"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	result, err := DiffTree(oldMatcher, newMatcher, root)
+	if err != nil {
+		t.Fatalf("DiffTree failed: %v", err)
+	}
+
+	// src/build is a file, so the directory pattern build/ never applied to it
+	if !reflect.DeepEqual(result.NewlyIgnored, []string{"dist/bundle.js"}) {
+		t.Errorf("Expected newly ignored [dist/bundle.js], got %v", result.NewlyIgnored)
+	}
+	if !reflect.DeepEqual(result.NewlyUnignored, []string{"build/app.js", "build/sub/lib.js"}) {
+		t.Errorf("Expected newly un-ignored build files, got %v", result.NewlyUnignored)
+	}
+
+	unchanged, err := DiffTree(oldMatcher, oldMatcher, root)
+	if err != nil {
+		t.Fatalf("DiffTree failed: %v", err)
+	}
+	if !unchanged.Empty() {
+		t.Errorf("Expected no changes when comparing a matcher with itself, got %+v", unchanged)
+	}
+
+	if _, err := DiffTree(oldMatcher, newMatcher, filepath.Join(root, "missing")); err == nil {
+		t.Error("Expected error for missing root")
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/codeglyph/go-dotignore/internal"
+	"golang.org/x/text/unicode/norm"
 )
 
 type ignorePattern struct {
@@ -17,154 +22,446 @@ type ignorePattern struct {
 	regexPattern *regexp.Regexp
 	isDirectory  bool // true if pattern ends with /
 	negate       bool
-	hasWildcard  bool // true if pattern contains wildcards
+	hasWildcard  bool   // true if pattern contains wildcards
+	bytesOnly    bool   // true if pattern is not valid UTF-8 and only applies to byte paths
+	line         int    // 1-based line number the pattern was read from
+	text         string // the pattern as written, without surrounding whitespace
 }
 
 // PatternMatcher provides methods to parse, store, and evaluate ignore patterns against file paths.
+// A PatternMatcher is immutable once constructed and is safe for concurrent use by multiple
+// goroutines. Use ReloadableMatcher when the rule set needs to change at runtime.
 type PatternMatcher struct {
 	ignorePatterns []ignorePattern
+	options        options
+	cache          *resultCache
+
+	// bytes is the matcher MatchesBytes delegates to, built on first use
+	bytesOnce sync.Once
+	bytes     *PatternMatcher
+	bytesErr  error
 }
 
 // NewPatternMatcher initializes a new PatternMatcher instance from a list of string patterns.
-func NewPatternMatcher(patterns []string) (*PatternMatcher, error) {
-	ignorePatterns, err := buildIgnorePatterns(patterns)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build ignore patterns: %w", err)
-	}
-	return &PatternMatcher{
-		ignorePatterns: ignorePatterns,
-	}, nil
+func NewPatternMatcher(patterns []string, opts ...Option) (*PatternMatcher, error) {
+	return newPatternMatcher(patterns, buildOptions(opts))
 }
 
 // NewPatternMatcherFromReader initializes a new PatternMatcher instance from an io.Reader.
-func NewPatternMatcherFromReader(reader io.Reader) (*PatternMatcher, error) {
+func NewPatternMatcherFromReader(reader io.Reader, opts ...Option) (*PatternMatcher, error) {
 	if reader == nil {
 		return nil, errors.New("reader cannot be nil")
 	}
 
-	patterns, err := internal.ReadLines(reader)
+	o := buildOptions(opts)
+	patterns, err := readPatterns(reader, o.limits.MaxPatternLength)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse patterns from reader: %w", err)
 	}
-	return NewPatternMatcher(patterns)
+	return newPatternMatcher(patterns, o)
 }
 
 // NewPatternMatcherFromFile reads a file containing ignore patterns and returns a PatternMatcher instance.
-func NewPatternMatcherFromFile(filePath string) (*PatternMatcher, error) {
+// The directory containing the file becomes the root of the matcher, so that absolute paths below
+// it can be matched; see WithRoot.
+func NewPatternMatcherFromFile(filePath string, opts ...Option) (*PatternMatcher, error) {
 	if filePath == "" {
 		return nil, errors.New("file path cannot be empty")
 	}
 
+	o := fileOptions(filePath, opts)
+	patterns, err := readPatternFile(filePath, o.limits.MaxPatternLength)
+	if err != nil {
+		return nil, err
+	}
+	return newPatternMatcher(patterns, o)
+}
+
+// fileOptions resolves the options of a matcher read from filePath, whose root defaults to the
+// directory containing the file.
+func fileOptions(filePath string, opts []Option) options {
+	return withSourceFile(buildOptions(opts), filePath)
+}
+
+// withSourceFile returns o for a matcher read from filePath. Unless the root was set with
+// WithRoot, it becomes the directory containing the file.
+func withSourceFile(o options, filePath string) options {
+	o.sourceFile = filePath
+	if !o.rootSet {
+		o.root = rootDir(filepath.Dir(filePath))
+	}
+	return o
+}
+
+// readPatternFile reads the raw pattern lines of an ignore file, failing on lines longer than
+// maxLength bytes unless it is zero.
+func readPatternFile(filePath string, maxLength int) ([]string, error) {
 	fileReader, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %q: %w", filePath, err)
 	}
 	defer fileReader.Close()
 
-	patterns, err := internal.ReadLines(fileReader)
+	patterns, err := readPatterns(fileReader, maxLength)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse patterns from file %q: %w", filePath, err)
+		return nil, fmt.Errorf("failed to parse patterns from file %q: %w", filePath, withFile(err, filePath))
 	}
-	return NewPatternMatcher(patterns)
+	return patterns, nil
+}
+
+// readPatterns reads the raw pattern lines from reader. A line longer than maxLength is reported
+// as a ParseError for ErrPatternTooLong as soon as it is seen, without reading the rest of it.
+func readPatterns(reader io.Reader, maxLength int) ([]string, error) {
+	patterns, err := internal.ReadLinesMax(reader, maxLength)
+	var lineErr *internal.LineError
+	if errors.Is(err, internal.ErrLineTooLong) && errors.As(err, &lineErr) {
+		reason := fmt.Errorf("%w: limit is %d", ErrPatternTooLong, maxLength)
+		return nil, &ParseError{Line: lineErr.Line, Column: maxLength + 1, Reason: reason}
+	}
+	return patterns, err
+}
+
+// newPatternMatcher builds a PatternMatcher with already resolved options.
+func newPatternMatcher(patterns []string, opts options) (*PatternMatcher, error) {
+	ignorePatterns, err := compilePatterns(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &PatternMatcher{
+		ignorePatterns: ignorePatterns,
+		options:        opts,
+		cache:          newResultCache(opts.cacheSize),
+	}, nil
+}
+
+// compilePatterns checks the pattern lines against the limits of opts and parses them.
+func compilePatterns(patterns []string, opts options) ([]ignorePattern, error) {
+	if err := checkLimits(patterns, opts); err != nil {
+		return nil, err
+	}
+	ignorePatterns, err := buildIgnorePatterns(normalizePatterns(patterns, opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ignore patterns: %w", withFile(err, opts.sourceFile))
+	}
+	return ignorePatterns, nil
+}
+
+// checkLimits returns a ParseError for the first pattern line that exceeds the limits of opts.
+func checkLimits(patterns []string, opts options) error {
+	if _, diagnostics := applyLimits(patterns, opts.limits); len(diagnostics) > 0 {
+		d := diagnostics[0]
+		err := &ParseError{Line: d.Line, Column: d.Column, Pattern: d.Pattern, Reason: d.Reason}
+		return fmt.Errorf("failed to build ignore patterns: %w", withFile(err, opts.sourceFile))
+	}
+	return nil
 }
 
 // Matches checks if the given file path matches any of the ignore patterns in the PatternMatcher.
 // It returns true if the file should be ignored, false otherwise.
+// Since Matches does not know whether file is a directory, directory patterns such as "build/"
+// also match a file named "build"; use MatchesPath when the type of the path is known.
 func (p *PatternMatcher) Matches(file string) (bool, error) {
-	if file == "" {
-		return false, nil
+	var info pathInfo
+	return p.matchWithBuffer(&info, file, true)
+}
+
+// MatchesPath is like Matches, but takes whether the path is a directory into account:
+// directory patterns only match a non-directory path through one of its parent directories.
+func (p *PatternMatcher) MatchesPath(file string, isDir bool) (bool, error) {
+	var info pathInfo
+	return p.matchWithBuffer(&info, file, isDir)
+}
+
+// matchWithBuffer is MatchesPath with a caller-provided pathInfo, which lets batch callers reuse
+// its buffer across paths.
+func (p *PatternMatcher) matchWithBuffer(info *pathInfo, file string, isDir bool) (bool, error) {
+	file, ok, err := p.normalizePath(file)
+	if err != nil || !ok {
+		return false, err
 	}
 
-	// Clean and normalize the path
-	file = filepath.Clean(file)
-	if file == "." || file == "./" {
-		return false, nil
+	if p.cache != nil {
+		if matched, ok := p.cache.get(file, isDir); ok {
+			return matched, nil
+		}
 	}
 
-	// Convert backslashes to forward slashes for consistent matching
-	// Use explicit conversion to handle all cases
-	file = strings.ReplaceAll(file, "\\", "/")
+	info.reset(file, isDir)
+	matched, err := p.matchesInternal(info)
+	if err != nil {
+		return false, err
+	}
 
-	return p.matchesInternal(file)
+	if p.cache != nil {
+		p.cache.add(file, isDir, matched)
+	}
+	return matched, nil
 }
 
-func buildIgnorePatterns(patterns []string) ([]ignorePattern, error) {
-	var ignorePatterns []ignorePattern
+// normalizeMatchPath cleans a path for matching. It returns false for paths that denote the
+// root of the tree and therefore can never be ignored.
+func normalizeMatchPath(file string) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+
+	// Convert backslashes to forward slashes before cleaning, so that paths are cleaned the
+	// same way on every platform
+	file = path.Clean(strings.ReplaceAll(file, "\\", "/"))
+	if file == "." {
+		return "", false
+	}
+	return file, true
+}
 
-	for i, pattern := range patterns {
-		pattern = strings.TrimSpace(pattern)
+// normalizePath prepares a path for matching: it cleans it and makes it relative to the root with
+// relativePath, converts it to NFC if the matcher was built with WithUnicodeNormalization, and
+// checks it against the limits. It returns false for paths that denote the root.
+func (p *PatternMatcher) normalizePath(file string) (string, bool, error) {
+	file, ok, err := p.relativePath(file)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if p.options.normalize {
+		file = norm.NFC.String(file)
+	}
+	return file, true, p.checkPath(file)
+}
 
-		// Skip empty lines and comments
-		if pattern == "" || strings.HasPrefix(pattern, "#") {
-			continue
-		}
+// relativePath cleans a path with normalizeMatchPath. If the matcher has a root, absolute paths
+// are first made relative to it, and paths that lead out of it return ErrOutsideRoot.
+func (p *PatternMatcher) relativePath(file string) (string, bool, error) {
+	root := p.options.root
+	if root == "" {
+		name, ok := normalizeMatchPath(file)
+		return name, ok, nil
+	}
 
-		// Handle negation
-		isNegation := strings.HasPrefix(pattern, "!")
-		if isNegation {
-			if len(pattern) == 1 {
-				return nil, fmt.Errorf("invalid pattern at line %d: single '!' is not allowed", i+1)
-			}
-			pattern = pattern[1:]
+	name := file
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: %q is not within %q", ErrOutsideRoot, file, root)
 		}
+		name = rel
+	}
+	name, ok := normalizeMatchPath(name)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", false, fmt.Errorf("%w: %q is not within %q", ErrOutsideRoot, file, root)
+	}
+	return name, ok, nil
+}
+
+// normalizePatterns converts the pattern lines to NFC if opts enable Unicode normalization.
+func normalizePatterns(patterns []string, opts options) []string {
+	if !opts.normalize {
+		return patterns
+	}
+	normalized := make([]string, len(patterns))
+	for i, line := range patterns {
+		normalized[i] = norm.NFC.String(line)
+	}
+	return normalized
+}
 
-		// Convert backslashes to forward slashes for consistent handling
-		// filepath.ToSlash might not handle all cases, so we'll be explicit
-		pattern = strings.ReplaceAll(pattern, "\\", "/")
+// pathInfo holds a normalized path together with the offsets of its components, so that
+// component matching can slice the path instead of splitting and joining it.
+// The offsets slice is reused across calls to reset.
+type pathInfo struct {
+	path   string
+	starts []int
+	isDir  bool
+}
 
-		// Check if pattern is for directories only (after normalization)
-		isDirectory := strings.HasSuffix(pattern, "/")
-		if isDirectory {
-			pattern = strings.TrimSuffix(pattern, "/")
+// reset points the pathInfo at a new normalized path.
+func (pi *pathInfo) reset(path string, isDir bool) {
+	pi.path = path
+	pi.isDir = isDir
+	pi.starts = append(pi.starts[:0], 0)
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' {
+			pi.starts = append(pi.starts, i+1)
 		}
+	}
+}
 
-		// Validate pattern is not empty after processing
-		if pattern == "" {
-			return nil, fmt.Errorf("invalid pattern at line %d: pattern cannot be empty", i+1)
-		}
+// numComponents returns the number of slash-separated components in the path.
+func (pi *pathInfo) numComponents() int {
+	return len(pi.starts)
+}
 
-		// Check if pattern contains wildcards
-		hasWildcard := strings.ContainsAny(pattern, "*?")
+// component returns the i-th slash-separated component of the path.
+func (pi *pathInfo) component(i int) string {
+	end := len(pi.path)
+	if i+1 < len(pi.starts) {
+		end = pi.starts[i+1] - 1
+	}
+	return pi.path[pi.starts[i]:end]
+}
 
-		// Build regex pattern
-		regexPattern, err := internal.BuildRegex(pattern)
+// parent returns the pathInfo of the directory containing the path, sharing its buffer.
+// It returns false if the path has no parent.
+func (pi *pathInfo) parent() (pathInfo, bool) {
+	n := len(pi.starts)
+	if n < 2 {
+		return pathInfo{}, false
+	}
+	return pathInfo{path: pi.path[:pi.starts[n-1]-1], starts: pi.starts[:n-1], isDir: true}, true
+}
+
+func buildIgnorePatterns(patterns []string) ([]ignorePattern, error) {
+	var ignorePatterns []ignorePattern
+
+	for i, line := range patterns {
+		pattern, ok, err := parsePattern(line, i+1)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build regex for pattern %q at line %d: %w", pattern, i+1, err)
+			return nil, err
+		}
+		if ok {
+			ignorePatterns = append(ignorePatterns, pattern)
 		}
-
-		ignorePatterns = append(ignorePatterns, ignorePattern{
-			pattern:      pattern,
-			regexPattern: regexPattern,
-			isDirectory:  isDirectory,
-			negate:       isNegation,
-			hasWildcard:  hasWildcard,
-		})
 	}
 
 	return ignorePatterns, nil
 }
 
+// parsePattern parses a single line of an ignore file. It returns false for blank lines and
+// comments, and a *ParseError for invalid patterns.
+func parsePattern(line string, lineNumber int) (ignorePattern, bool, error) {
+	pattern := strings.TrimSpace(line)
+
+	// Skip empty lines and comments
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignorePattern{}, false, nil
+	}
+
+	// Byte offset of the pattern within the original line, for error columns
+	offset := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+
+	// Handle negation
+	isNegation := strings.HasPrefix(pattern, "!")
+	if isNegation {
+		if len(pattern) == 1 {
+			return ignorePattern{}, false, &ParseError{Line: lineNumber, Column: offset + 1, Pattern: line, Reason: ErrLoneNegation}
+		}
+		pattern = pattern[1:]
+		offset++
+	}
+
+	// Convert backslashes to forward slashes for consistent handling
+	// filepath.ToSlash might not handle all cases, so we'll be explicit
+	pattern = strings.ReplaceAll(pattern, "\\", "/")
+
+	// Check if pattern is for directories only (after normalization)
+	isDirectory := strings.HasSuffix(pattern, "/")
+	if isDirectory {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	// Validate pattern is not empty after processing
+	if pattern == "" {
+		return ignorePattern{}, false, &ParseError{Line: lineNumber, Column: offset + 1, Pattern: line, Reason: ErrEmptyPattern}
+	}
+
+	// Check if pattern contains wildcards
+	hasWildcard := strings.ContainsAny(pattern, "*?")
+
+	// A pattern that is not valid UTF-8, such as a line of a Latin-1 file, names raw bytes. It is
+	// compiled over the runes of toByteRunes, which only the byte matcher uses
+	bytesOnly := !utf8.ValidString(pattern)
+	if bytesOnly {
+		pattern = toByteRunes(pattern)
+	}
+
+	// Build regex pattern. Patterns with wildcards are also tried against every suffix of a path,
+	// which their regex does in a single pass by accepting any leading directories
+	buildRegex := internal.BuildRegex
+	if hasWildcard {
+		buildRegex = internal.BuildSuffixRegex
+	}
+	regexPattern, err := buildRegex(pattern)
+	if err != nil {
+		column := offset + 1
+		var patternErr *internal.PatternError
+		if errors.As(err, &patternErr) {
+			column += patternErr.Offset
+			err = patternErr.Err
+		}
+		return ignorePattern{}, false, &ParseError{Line: lineNumber, Column: column, Pattern: line, Reason: err}
+	}
+
+	return ignorePattern{
+		pattern:      pattern,
+		regexPattern: regexPattern,
+		isDirectory:  isDirectory,
+		negate:       isNegation,
+		hasWildcard:  hasWildcard,
+		bytesOnly:    bytesOnly,
+		line:         lineNumber,
+		text:         strings.TrimSpace(line),
+	}, true, nil
+}
+
 // matchesInternal performs the actual pattern matching logic
-func (p *PatternMatcher) matchesInternal(file string) (bool, error) {
+func (p *PatternMatcher) matchesInternal(info *pathInfo) (bool, error) {
+	matched, _, err := p.evaluate(info, nil)
+	return matched, err
+}
+
+// evaluate matches info against every pattern and returns the result together with the index of
+// the decisive pattern, the last one that matched, or -1 if none did. If record is not nil, it is
+// called with the index of every matching pattern. Tracked paths match no pattern.
+func (p *PatternMatcher) evaluate(info *pathInfo, record func(index int)) (bool, int, error) {
 	matched := false
+	decisive := -1
+
+	if p.isTracked(info.path) {
+		return matched, decisive, nil
+	}
+
+	parent, hasParent := info.parent()
+
+	for i, pattern := range p.ignorePatterns {
+		if pattern.bytesOnly {
+			continue
+		}
+		target := info
+		if pattern.isDirectory && !info.isDir {
+			// A directory pattern can only match a file through the directory containing it
+			if !hasParent {
+				continue
+			}
+			target = &parent
+		}
 
-	for _, pattern := range p.ignorePatterns {
-		isMatch, err := p.matchPattern(file, pattern)
+		isMatch, err := p.matchPattern(target, pattern)
 		if err != nil {
-			return false, fmt.Errorf("error matching pattern %q against file %q: %w", pattern.pattern, file, err)
+			return false, -1, fmt.Errorf("error matching pattern %q against file %q: %w", pattern.pattern, info.path, err)
 		}
 
 		if isMatch {
 			matched = !pattern.negate
+			decisive = i
+			if record != nil {
+				record(i)
+			}
 		}
 	}
 
-	return matched, nil
+	return matched, decisive, nil
+}
+
+// isTracked reports whether the path is tracked according to the WithTracked option.
+func (p *PatternMatcher) isTracked(path string) bool {
+	return p.options.tracked != nil && p.options.tracked.Tracked(path)
 }
 
 // matchPattern checks if a file matches a specific pattern
-func (p *PatternMatcher) matchPattern(file string, pattern ignorePattern) (bool, error) {
-	// Try the regex pattern first
+func (p *PatternMatcher) matchPattern(info *pathInfo, pattern ignorePattern) (bool, error) {
+	file := info.path
+
+	// Try the regex pattern first; for patterns with wildcards, it also matches every suffix of the
+	// path, such as "b/c.txt" of "a/b/c.txt" for "b/*.txt"
 	if pattern.regexPattern.MatchString(file) {
 		return true, nil
 	}
@@ -181,33 +478,6 @@ func (p *PatternMatcher) matchPattern(file string, pattern ignorePattern) (bool,
 		}
 	}
 
-	// For patterns with wildcards, try matching parts of the path
-	if pattern.hasWildcard {
-		parts := strings.Split(file, "/")
-
-		// For patterns like "src/*.txt", try matching against subpaths
-		for i := 0; i < len(parts); i++ {
-			subPath := strings.Join(parts[i:], "/")
-			if pattern.regexPattern.MatchString(subPath) {
-				return true, nil
-			}
-		}
-
-		// Also try matching the full path from different starting points
-		for i := 0; i < len(parts); i++ {
-			if i == 0 {
-				continue // already tried full path above
-			}
-			prefixPath := strings.Join(parts[:i], "/")
-			remainingPath := strings.Join(parts[i:], "/")
-
-			// Check if pattern could match from this point
-			if pattern.regexPattern.MatchString(prefixPath + "/" + remainingPath) {
-				return true, nil
-			}
-		}
-	}
-
 	// For patterns with path separators, try matching as substring
 	if strings.Contains(pattern.pattern, "/") {
 		// Pattern like "src/test.txt" should match exactly or as part of path
@@ -226,9 +496,8 @@ func (p *PatternMatcher) matchPattern(file string, pattern ignorePattern) (bool,
 
 	// For simple patterns (no path separators), check filename components
 	if !strings.Contains(pattern.pattern, "/") {
-		parts := strings.Split(file, "/")
-		for _, part := range parts {
-			if pattern.regexPattern.MatchString(part) {
+		for i := 0; i < info.numComponents(); i++ {
+			if pattern.regexPattern.MatchString(info.component(i)) {
 				return true, nil
 			}
 		}
@@ -411,6 +411,23 @@ func TestNewPatternMatcherFromReaderErrors(t *testing.T) {
 	})
 }
 
+func TestNewPatternMatcherFromReaderLongLines(t *testing.T) {
+	long := strings.Repeat("a", 100*1024)
+	input := "# generated\r\n" + long + "\r\n*.log\rbuild/\n"
+
+	matcher, err := NewPatternMatcherFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, path := range []string{long, "debug.log", "build"} {
+		isDir := path == "build"
+		if ignored, err := matcher.MatchesPath(path, isDir); err != nil || !ignored {
+			t.Errorf("Expected %.20q to be ignored, got %v (%v)", path, ignored, err)
+		}
+	}
+}
+
 func TestNewPatternMatcherFromFileErrors(t *testing.T) {
 	t.Run("Empty filepath", func(t *testing.T) {
 		_, err := NewPatternMatcherFromFile("")
@@ -543,6 +560,9 @@ func TestWindowsPaths(t *testing.T) {
 		{"src\\test.txt", true},
 		{"build/", true},
 		{"build\\", true},
+		{"src\\sub\\..\\test.txt", true},
+		{"lib\\..\\build\\out.o", true},
+		{"src\\..\\test.txt", false},
 	}
 
 	for _, tt := range tests {
@@ -611,3 +631,38 @@ func BenchmarkMatches(b *testing.B) {
 		}
 	}
 }
+
+func TestMatchesPath(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"build/", "*.log", "docs/tmp/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		isDir    bool
+		expected bool
+	}{
+		{"build", true, true},
+		{"build", false, false},
+		{"build/app.js", false, true},
+		{"src/build", false, false},
+		{"src/build/app.js", false, true},
+		{"app.log", false, true},
+		{"docs/tmp", true, true},
+		{"docs/tmp", false, false},
+		{"docs/tmp/a.txt", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := matcher.MatchesPath(tt.file, tt.isDir)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("File %q (dir=%v): expected %v, got %v", tt.file, tt.isDir, tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+// Sentinel errors for the categories of invalid patterns. They are reported as the Reason of a
+// ParseError and can be tested with errors.Is.
+var (
+	// ErrEmptyPattern is reported for patterns that are empty once negation and trailing slashes
+	// are removed, such as "/" or "!/".
+	ErrEmptyPattern = errors.New("pattern cannot be empty")
+	// ErrLoneNegation is reported for a line consisting of a single "!".
+	ErrLoneNegation = errors.New("single '!' is not allowed")
+	// ErrBadBracket is reported for character classes that cannot be compiled, such as "[z-a]".
+	ErrBadBracket = internal.ErrBadBracket
+
+	// ErrTooManyPatterns is reported when a rule set has more patterns than Limits.MaxPatterns.
+	ErrTooManyPatterns = errors.New("too many patterns")
+	// ErrPatternTooLong is reported for lines longer than Limits.MaxPatternLength.
+	ErrPatternTooLong = errors.New("pattern too long")
+	// ErrTooManyDoubleStars is reported for patterns with more "**" than Limits.MaxDoubleStars.
+	ErrTooManyDoubleStars = errors.New("too many ** wildcards")
+)
+
+// Errors returned when matching paths that exceed the Limits of a PatternMatcher.
+var (
+	// ErrPathTooLong is returned for paths longer than Limits.MaxPathLength.
+	ErrPathTooLong = errors.New("path too long")
+	// ErrPathTooDeep is returned for paths with more components than Limits.MaxPathDepth.
+	ErrPathTooDeep = errors.New("path too deep")
+)
+
+// ErrOutsideRoot is returned when matching a path that lies outside the root of a matcher, see
+// WithRoot.
+var ErrOutsideRoot = errors.New("path is outside the root")
+
+// ParseError describes an invalid pattern and where it was found. Use errors.As to retrieve it
+// from the errors returned by the constructors.
+type ParseError struct {
+	// File is the ignore file the pattern was read from, if any.
+	File string
+	// Line is the 1-based line number of the pattern.
+	Line int
+	// Column is the 1-based byte column of the offending character within the line.
+	Column int
+	// Pattern is the line as it was given, before trimming.
+	Pattern string
+	// Reason describes what is wrong; it wraps one of the sentinel errors where applicable.
+	Reason error
+}
+
+func (e *ParseError) Error() string {
+	location := fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	if e.File != "" {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	return fmt.Sprintf("invalid pattern %q at %s: %v", e.Pattern, location, e.Reason)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Reason
+}
+
+// withFile records the file name on a ParseError contained in err, if any.
+func withFile(err error, filePath string) error {
+	var parseErr *ParseError
+	if filePath != "" && errors.As(err, &parseErr) && parseErr.File == "" {
+		parseErr.File = filePath
+	}
+	return err
+}
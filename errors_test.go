@@ -0,0 +1,92 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		line     int
+		column   int
+		reason   error
+	}{
+		{
+			name:     "Lone negation",
+			patterns: []string{"*.log", "  !"},
+			line:     2,
+			column:   3,
+			reason:   ErrLoneNegation,
+		},
+		{
+			name:     "Empty pattern",
+			patterns: []string{"# comment", "", " !/"},
+			line:     3,
+			column:   3,
+			reason:   ErrEmptyPattern,
+		},
+		{
+			name:     "Reversed range after leading bracket",
+			patterns: []string{"file[]z-a]x"},
+			line:     1,
+			column:   5,
+			reason:   ErrBadBracket,
+		},
+		{
+			name:     "Reversed range",
+			patterns: []string{"ok", "\t!src/[z-a].txt"},
+			line:     2,
+			column:   7,
+			reason:   ErrBadBracket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPatternMatcher(tt.patterns)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %T: %v", err, err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("Expected line %d column %d, got line %d column %d", tt.line, tt.column, parseErr.Line, parseErr.Column)
+			}
+			if parseErr.Pattern != tt.patterns[tt.line-1] {
+				t.Errorf("Expected pattern %q, got %q", tt.patterns[tt.line-1], parseErr.Pattern)
+			}
+			if !errors.Is(err, tt.reason) {
+				t.Errorf("Expected error to wrap %v, got %v", tt.reason, err)
+			}
+		})
+	}
+}
+
+func TestParseErrorFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("*.log\n!\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	_, err := NewPatternMatcherFromFile(path)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError, got %v", err)
+	}
+	if parseErr.File != path {
+		t.Errorf("Expected file %q, got %q", path, parseErr.File)
+	}
+
+	expected := path + ":2:1"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error message to contain %q, got: %v", expected, err)
+	}
+}
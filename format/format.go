@@ -0,0 +1,154 @@
+// Package format rewrites ignore files in a canonical form without changing which paths they
+// ignore.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+// Options controls optional formatting steps.
+type Options struct {
+	// Sort orders patterns alphabetically within each section. A section is a block of pattern
+	// lines delimited by comments or blank lines. Only consecutive patterns of the same kind
+	// (ignore or negation) are reordered, so the result ignores exactly the same paths.
+	Sort bool
+}
+
+// Format returns the canonical form of an ignore file:
+//
+//   - surrounding whitespace is removed from every line;
+//   - backslashes in patterns become slashes, as they do when patterns are parsed;
+//   - runs of three or more stars become "**" and repeated "**/" segments collapse into one;
+//   - exact duplicate patterns are removed, keeping the last occurrence, which is the one that
+//     decides the outcome for the paths it matches;
+//   - runs of blank lines collapse into one, and leading and trailing blank lines are dropped.
+//
+// Comments and the blank lines separating sections are preserved. The result always ends with a
+// newline unless it is empty.
+func Format(src []byte, opts Options) ([]byte, error) {
+	lines, err := internal.ReadLines(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patterns: %w", err)
+	}
+
+	canonical := make([]string, len(lines))
+	for i, line := range lines {
+		canonical[i] = canonicalLine(line)
+	}
+	canonical = removeDuplicates(canonical)
+
+	if opts.Sort {
+		sortSections(canonical)
+	}
+
+	var out bytes.Buffer
+	blank := false
+	for _, line := range canonical {
+		if line == "" {
+			blank = out.Len() > 0
+			continue
+		}
+		if blank {
+			out.WriteByte('\n')
+			blank = false
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// canonicalLine returns the canonical form of a single line.
+func canonicalLine(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || isComment(line) {
+		return line
+	}
+
+	line = strings.ReplaceAll(line, "\\", "/")
+
+	// Collapse runs of three or more stars into "**"
+	for strings.Contains(line, "***") {
+		line = strings.ReplaceAll(line, "***", "**")
+	}
+
+	// Collapse repeated "**" segments; "**/**" matches the same paths as "**"
+	for {
+		collapsed := collapseDoubleStars(line)
+		if collapsed == line {
+			break
+		}
+		line = collapsed
+	}
+	return line
+}
+
+// collapseDoubleStars removes one "**/" segment that is immediately followed by another "**"
+// segment.
+func collapseDoubleStars(line string) string {
+	for i := 0; i+5 <= len(line); i++ {
+		if line[i:i+5] != "**/**" {
+			continue
+		}
+		startsSegment := i == 0 || line[i-1] == '/' || (i == 1 && line[0] == '!')
+		endsSegment := i+5 == len(line) || line[i+5] == '/'
+		if startsSegment && endsSegment {
+			return line[:i] + line[i+3:]
+		}
+	}
+	return line
+}
+
+// removeDuplicates blanks out patterns that occur again later in the file. The last occurrence
+// is kept: an earlier identical pattern can never be the last match for any path.
+func removeDuplicates(lines []string) []string {
+	last := make(map[string]int)
+	for i, line := range lines {
+		if line != "" && !isComment(line) {
+			last[line] = i
+		}
+	}
+
+	result := lines[:0]
+	for i, line := range lines {
+		if line != "" && !isComment(line) && last[line] != i {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// sortSections sorts runs of consecutive patterns of the same kind in place.
+func sortSections(lines []string) {
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && isPattern(lines[i]) && isNegation(lines[i]) == isNegation(lines[start]) && isPattern(lines[start]) {
+			continue
+		}
+		if start < i && isPattern(lines[start]) {
+			sort.Strings(lines[start:i])
+		}
+		start = i
+	}
+}
+
+// isPattern reports whether a canonical line is a pattern rather than a comment or blank line.
+func isPattern(line string) bool {
+	return line != "" && !isComment(line)
+}
+
+// isComment reports whether a trimmed line is a comment.
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#")
+}
+
+// isNegation reports whether a pattern re-includes paths.
+func isNegation(line string) bool {
+	return strings.HasPrefix(line, "!")
+}
@@ -0,0 +1,114 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "Already canonical",
+			input:    "# Logs\n*.log\n\n# Build\nbuild/\n",
+			expected: "# Logs\n*.log\n\n# Build\nbuild/\n",
+		},
+		{
+			name:     "Whitespace and blank lines",
+			input:    "\n\n  *.log  \n\n\n\nbuild/\t\n\n",
+			expected: "*.log\n\nbuild/\n",
+		},
+		{
+			name:     "Backslashes",
+			input:    "src\\gen\\\n!src\\gen\\keep.go\n# C:\\comments\\stay\n",
+			expected: "src/gen/\n!src/gen/keep.go\n# C:\\comments\\stay\n",
+		},
+		{
+			name:     "Double stars",
+			input:    "**/**/node_modules/\nlogs/**/**/**\na/***/b\n!**/**/keep\nfoo**/**bar\n",
+			expected: "**/node_modules/\nlogs/**\na/**/b\n!**/keep\nfoo**/**bar\n",
+		},
+		{
+			name:     "Duplicates keep last occurrence",
+			input:    "*.log\n!debug.log\n*.log\ndist/  \ndist/\n",
+			expected: "!debug.log\n*.log\ndist/\n",
+		},
+		{
+			name:     "CRLF and missing final newline",
+			input:    "*.log\r\nbuild/",
+			expected: "*.log\nbuild/\n",
+		},
+		{
+			name:     "Empty input",
+			input:    "\n\n",
+			expected: "",
+		},
+		{
+			name:     "Sort within sections",
+			input:    "# B\nzeta\nalpha\n!keep\nomega\nbeta\n\n# A\nyy\nxx\n",
+			opts:     Options{Sort: true},
+			expected: "# B\nalpha\nzeta\n!keep\nbeta\nomega\n\n# A\nxx\nyy\n",
+		},
+		{
+			name:     "No sorting by default",
+			input:    "zeta\nalpha\n",
+			expected: "zeta\nalpha\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Format([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, string(result))
+			}
+
+			// Formatting is idempotent
+			again, err := Format(result, tt.opts)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if string(again) != string(result) {
+				t.Errorf("Expected formatting to be idempotent, got %q then %q", result, again)
+			}
+		})
+	}
+}
+
+func TestFormatPreservesMatches(t *testing.T) {
+	input := "*.log\n!debug.log\n*.log\nsrc\\gen\\\n**/**/tmp/\nzeta/\nalpha/\n!alpha/keep\nbuild/\n***/cache\nx/***/y\n"
+	paths := []string{
+		"app.log", "debug.log", "src/gen/x.go", "a/tmp/file", "tmp/file", "zeta/x", "alpha/x",
+		"alpha/keep", "build/out", "src/main.go", "cache", "a/b/cache", "x/y", "x/m/n/y",
+	}
+
+	original, err := dotignore.NewPatternMatcherFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	formatted, err := Format([]byte(input), Options{Sort: true})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	canonical, err := dotignore.NewPatternMatcherFromReader(strings.NewReader(string(formatted)))
+	if err != nil {
+		t.Fatalf("Failed to create matcher from formatted output: %v", err)
+	}
+
+	for _, path := range paths {
+		want, _ := original.Matches(path)
+		got, _ := canonical.Matches(path)
+		if got != want {
+			t.Errorf("Path %q: formatted rules give %v, original rules give %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package dotignore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FilterFS returns a view of fsys in which the paths matcher ignores do not exist. Opening or
+// statting an ignored path, or any path below an ignored directory, fails with fs.ErrNotExist,
+// and directory listings omit ignored entries, so fs.WalkDir, fs.Glob and other consumers such
+// as http.FS or html/template.ParseFS see only the remaining files. Paths are matched relative to
+// the root of fsys, using the names as the directory listings spell them, so that ".ENV" cannot
+// open ".env" on a file system that ignores case.
+func FilterFS(fsys fs.FS, matcher *PatternMatcher) fs.FS {
+	f := &filteredFS{fsys: fsys}
+	f.pathFilter = pathFilter{matcher: matcher, exists: f.exists, names: f.names}
+	return f
+}
+
+// filteredFS implements FilterFS.
+type filteredFS struct {
+	pathFilter
+	fsys fs.FS
+}
+
+// exists reports whether name exists in the underlying file system.
+func (f *filteredFS) exists(name string) bool {
+	_, err := fs.Stat(f.fsys, name)
+	return err == nil
+}
+
+// names lists the names in directory dir of the underlying file system.
+func (f *filteredFS) names(dir string) ([]string, error) {
+	entries, err := fs.ReadDir(f.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+// Open implements fs.FS.
+func (f *filteredFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	real, err := f.realPath(name)
+	if err != nil {
+		return nil, notExist("open", name, nil)
+	}
+	if hidden, err := f.ancestorIgnored(real); err != nil || hidden {
+		return nil, notExist("open", name, err)
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if hidden, err := f.ignored(real, info.IsDir()); err != nil || hidden {
+		file.Close()
+		return nil, notExist("open", name, err)
+	}
+
+	if dir, ok := file.(fs.ReadDirFile); ok && info.IsDir() {
+		return &filteredDir{ReadDirFile: dir, fs: f, name: real}, nil
+	}
+	return file, nil
+}
+
+// Stat implements fs.StatFS.
+func (f *filteredFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	real, err := f.realPath(name)
+	if err != nil {
+		return nil, notExist("stat", name, nil)
+	}
+	if hidden, err := f.ancestorIgnored(real); err != nil || hidden {
+		return nil, notExist("stat", name, err)
+	}
+
+	info, err := fs.Stat(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if hidden, err := f.ignored(real, info.IsDir()); err != nil || hidden {
+		return nil, notExist("stat", name, err)
+	}
+	return info, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *filteredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	real, err := f.realPath(name)
+	if err != nil {
+		return nil, notExist("readdir", name, nil)
+	}
+	if hidden, err := f.ancestorIgnored(real); err != nil || hidden {
+		return nil, notExist("readdir", name, err)
+	}
+	if hidden, err := f.ignored(real, true); err != nil || hidden {
+		return nil, notExist("readdir", name, err)
+	}
+
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return f.filterEntries(real, entries)
+}
+
+// filterEntries removes the ignored entries of directory dir.
+func (f *filteredFS) filterEntries(dir string, entries []fs.DirEntry) ([]fs.DirEntry, error) {
+	kept := entries[:0]
+	for _, entry := range entries {
+		hidden, err := f.ignored(path.Join(dir, entry.Name()), entry.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		if !hidden {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// pathFilter decides which slash-separated paths, relative to the root of a file system, are
+// hidden from its users.
+type pathFilter struct {
+	matcher *PatternMatcher
+	// exists reports whether a path exists in the file system, and names lists the names in one
+	// of its directories, "." being its root
+	exists func(name string) bool
+	names  func(dir string) ([]string, error)
+}
+
+// realPath returns name with every component spelled as in the listing of its directory. A
+// component that is not listed as given is replaced by the entry that equals it when case and
+// Unicode normalization are ignored, which is the file a case-insensitive file system opens for it.
+func (f pathFilter) realPath(name string) (string, error) {
+	if name == "." {
+		return name, nil
+	}
+
+	real := "."
+	for _, component := range strings.Split(name, "/") {
+		entry, err := f.realName(real, component)
+		if err != nil {
+			return "", err
+		}
+		real = path.Join(real, entry)
+	}
+	return real, nil
+}
+
+// realName returns the spelling of name in the listing of the directory dir. The directory is
+// only listed if another spelling of name opens as well, as it does when the file system ignores
+// case; otherwise name is the only spelling there is.
+func (f pathFilter) realName(dir, name string) (string, error) {
+	if !f.hasOtherSpelling(dir, name) {
+		return name, nil
+	}
+
+	names, err := f.names(dir)
+	if err != nil {
+		return "", err
+	}
+
+	folded := ""
+	for _, entry := range names {
+		if entry == name {
+			return name, nil
+		}
+		if folded == "" && strings.EqualFold(norm.NFC.String(entry), norm.NFC.String(name)) {
+			folded = entry
+		}
+	}
+	if folded == "" {
+		return "", fs.ErrNotExist
+	}
+	return folded, nil
+}
+
+// ignored reports whether name itself is ignored. The root is never ignored.
+func (f pathFilter) ignored(name string, isDir bool) (bool, error) {
+	if name == "." {
+		return false, nil
+	}
+	return f.matcher.MatchesPath(name, isDir)
+}
+
+// ancestorIgnored reports whether any directory containing name is ignored.
+func (f pathFilter) ancestorIgnored(name string) (bool, error) {
+	for i := 0; i < len(name); i++ {
+		if name[i] != '/' {
+			continue
+		}
+		if ignored, err := f.matcher.MatchesPath(name[:i], true); err != nil || ignored {
+			return ignored, err
+		}
+	}
+	return false, nil
+}
+
+// hasOtherSpelling reports whether name opens in dir with its case or Unicode normalization
+// changed.
+func (f pathFilter) hasOtherSpelling(dir, name string) bool {
+	tried := map[string]bool{name: true}
+	for _, other := range []string{strings.ToLower(name), strings.ToUpper(name), norm.NFC.String(name), norm.NFD.String(name)} {
+		if tried[other] {
+			continue
+		}
+		tried[other] = true
+		if f.exists(path.Join(dir, other)) {
+			return true
+		}
+	}
+	return false
+}
+
+// notExist returns the error reported for hidden paths, or err if matching failed.
+func notExist(op, name string, err error) error {
+	if err == nil {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// filteredDir is an open directory whose listing omits ignored entries.
+type filteredDir struct {
+	fs.ReadDirFile
+	fs   *filteredFS
+	name string
+}
+
+// ReadDir implements fs.ReadDirFile, returning up to n entries that are not ignored.
+func (d *filteredDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		if err != nil {
+			return nil, err
+		}
+		return d.fs.filterEntries(d.name, entries)
+	}
+
+	var result []fs.DirEntry
+	for len(result) < n {
+		entries, err := d.ReadDirFile.ReadDir(n - len(result))
+		kept, filterErr := d.fs.filterEntries(d.name, entries)
+		if filterErr != nil {
+			return result, filterErr
+		}
+		result = append(result, kept...)
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(result) > 0 {
+				return result, nil
+			}
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,189 @@
+package dotignore
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"README.md":              {Data: []byte("readme")},
+		".env":                   {Data: []byte("SECRET=1")},
+		"app.log":                {Data: []byte("log")},
+		"build/out.js":           {Data: []byte("out")},
+		"src/main.go":            {Data: []byte("main")},
+		"src/debug.log":          {Data: []byte("debug")},
+		"src/important.log":      {Data: []byte("keep")},
+		"src/node_modules/x.js":  {Data: []byte("x")},
+		"templates/index.tmpl":   {Data: []byte("index")},
+		"templates/partial.tmpl": {Data: []byte("partial")},
+	}
+}
+
+func newTestFSMatcher(t *testing.T) *PatternMatcher {
+	t.Helper()
+	matcher, err := NewPatternMatcher([]string{".env", "*.log", "!important.log", "build/", "node_modules/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	return matcher
+}
+
+func TestFilterFS(t *testing.T) {
+	fsys := FilterFS(newTestFS(), newTestFSMatcher(t))
+
+	expected := []string{
+		"README.md", "src/main.go", "src/important.log", "templates/index.tmpl", "templates/partial.tmpl",
+	}
+	if err := fstest.TestFS(fsys, expected...); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{".env", "app.log", "build", "build/out.js", "src/debug.log", "src/node_modules/x.js"} {
+		if _, err := fsys.Open(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+		if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+		if _, err := fs.ReadFile(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ReadFile(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+	}
+
+	if _, err := fsys.Open("../etc/passwd"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected fs.ErrInvalid for an invalid path, got %v", err)
+	}
+}
+
+func TestFilterFSWalkAndGlob(t *testing.T) {
+	fsys := FilterFS(newTestFS(), newTestFSMatcher(t))
+
+	var walked []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			walked = append(walked, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir failed: %v", err)
+	}
+
+	expected := []string{"README.md", "src/important.log", "src/main.go", "templates/index.tmpl", "templates/partial.tmpl"}
+	if !reflect.DeepEqual(walked, expected) {
+		t.Errorf("Expected %v, got %v", expected, walked)
+	}
+
+	logs, err := fs.Glob(fsys, "*/*.log")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if !reflect.DeepEqual(logs, []string{"src/important.log"}) {
+		t.Errorf("Expected only src/important.log, got %v", logs)
+	}
+}
+
+func TestFilterFSReadDirBatches(t *testing.T) {
+	fsys := FilterFS(newTestFS(), newTestFSMatcher(t))
+
+	dir, err := fsys.Open("src")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer dir.Close()
+
+	var names []string
+	for {
+		entries, err := dir.(fs.ReadDirFile).ReadDir(1)
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if !reflect.DeepEqual(names, []string{"important.log", "main.go"}) {
+		t.Errorf("Expected [important.log main.go], got %v", names)
+	}
+}
+
+// caseInsensitiveFS opens names of a MapFS regardless of case, as on macOS and Windows.
+type caseInsensitiveFS struct {
+	fsys fstest.MapFS
+}
+
+func (c caseInsensitiveFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	real := "."
+	if name != "." {
+		for _, part := range strings.Split(name, "/") {
+			entries, err := c.fsys.ReadDir(real)
+			if err != nil {
+				return nil, err
+			}
+			found := ""
+			for _, entry := range entries {
+				if strings.EqualFold(entry.Name(), part) {
+					found = entry.Name()
+					break
+				}
+			}
+			if found == "" {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+			}
+			real = path.Join(real, found)
+		}
+	}
+	return c.fsys.Open(real)
+}
+
+func TestFilterFSCaseInsensitive(t *testing.T) {
+	fsys := FilterFS(caseInsensitiveFS{newTestFS()}, newTestFSMatcher(t))
+
+	for _, name := range []string{".ENV", "App.Log", "BUILD/out.js", "src/DEBUG.log", "Src/Node_Modules/x.js"} {
+		if _, err := fs.ReadFile(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ReadFile(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+		if _, err := fs.Stat(fsys, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%q): expected fs.ErrNotExist, got %v", name, err)
+		}
+	}
+	if _, err := fs.ReadDir(fsys, "Build"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadDir(Build): expected fs.ErrNotExist, got %v", err)
+	}
+
+	if data, err := fs.ReadFile(fsys, "readme.MD"); err != nil || string(data) != "readme" {
+		t.Errorf("Expected readme.MD to open README.md, got %q (%v)", data, err)
+	}
+	entries, err := fs.ReadDir(fsys, "SRC")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"important.log", "main.go"}) {
+		t.Errorf("Expected [important.log main.go], got %v", names)
+	}
+
+	server := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer server.Close()
+	if status, _ := get(t, server.URL+"/.ENV"); status != http.StatusNotFound {
+		t.Errorf("GET /.ENV: Expected status 404, got %d", status)
+	}
+}
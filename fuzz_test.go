@@ -0,0 +1,86 @@
+package dotignore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+func FuzzMatches(f *testing.F) {
+	seeds := []struct {
+		patterns string
+		path     string
+		isDir    bool
+	}{
+		{"*.log\n!important.log", "logs/important.log", false},
+		{"build/\n!build/keep", "build/keep", false},
+		{"**/node_modules/**", "a/node_modules/b/c.js", false},
+		{"/root.txt\n# comment\n\n", "./root.txt", false},
+		{"[!x]y\n[a-", "ay", true},
+		{"\\#hash\n\\!bang", "#hash", false},
+		{"!", "a", false},
+		{"src\\*.go", "src\\main.go", false},
+		{"a/**/**/**/b", "a/x/y/z/b", true},
+		{"*", "//a//b//", true},
+		{"x\xff", "x\xff", false},
+	}
+	for _, seed := range seeds {
+		f.Add(seed.patterns, seed.path, seed.isDir)
+	}
+
+	f.Fuzz(func(t *testing.T, patterns, path string, isDir bool) {
+		lines := strings.Split(patterns, "\n")
+		if len(lines) > 64 {
+			lines = lines[:64]
+		}
+
+		lenient, diagnostics := NewPatternMatcherLenient(lines)
+		matcher, err := NewPatternMatcher(lines)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %v", err)
+			}
+			if parseErr.Line < 1 || parseErr.Line > len(lines) || parseErr.Column < 1 || parseErr.Column > len(lines[parseErr.Line-1])+1 {
+				t.Errorf("ParseError position out of range: %v", parseErr)
+			}
+			if len(diagnostics) == 0 {
+				t.Errorf("Expected lenient parsing to report %v", err)
+			}
+			matcher = lenient
+		}
+
+		ignored, err := matcher.MatchesPath(path, isDir)
+		if err != nil {
+			t.Fatalf("MatchesPath(%q, %v) returned error: %v", path, isDir, err)
+		}
+		if lenientIgnored, _ := lenient.MatchesPath(path, isDir); lenientIgnored != ignored {
+			t.Errorf("Lenient matcher disagrees for %q: %v, expected %v", path, lenientIgnored, ignored)
+		}
+
+		if normalized, _ := matcher.MatchesPath(internal.NormalizePath(path), isDir); normalized != ignored {
+			t.Errorf("Normalizing %q to %q changes the result from %v to %v", path, internal.NormalizePath(path), ignored, normalized)
+		}
+
+		matches, _ := matcher.Matches(path)
+		asDir, _ := matcher.MatchesPath(path, true)
+		if matches != asDir {
+			t.Errorf("Matches(%q) = %v, but MatchesPath with isDir = %v", path, matches, asDir)
+		}
+
+		// A cursor only differs from MatchesPath by ignoring paths below ignored directories
+		cursorIgnored, err := matcher.NewCursor().Matches(path)
+		if err != nil {
+			t.Fatalf("Cursor.Matches(%q) returned error: %v", path, err)
+		}
+		if matches && !cursorIgnored {
+			t.Errorf("Cursor does not ignore %q, which Matches ignores", path)
+		}
+
+		if _, err := matcher.Explain(path, isDir); err != nil {
+			t.Errorf("Explain(%q) returned error: %v", path, err)
+		}
+	})
+}
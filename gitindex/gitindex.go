@@ -0,0 +1,337 @@
+// Package gitindex reads the list of tracked paths from a git index file (.git/index), so that
+// ignore rules can be applied the way git applies them without running git. Index versions 2, 3
+// and 4 are supported, for both SHA-1 and SHA-256 repositories.
+package gitindex
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidIndex is returned, wrapped, when the data is not a valid git index.
+var ErrInvalidIndex = errors.New("invalid git index")
+
+const (
+	// flagExtended marks entries followed by a second flags field, in version 3 and later
+	flagExtended = 0x4000
+	// flagStageShift is the position of the merge stage within the flags field
+	flagStageShift = 12
+	// flagNameMask holds the length of the path, saturated at 0xfff
+	flagNameMask = 0xfff
+
+	extendedSkipWorktree = 0x4000
+	extendedIntentToAdd  = 0x2000
+
+	// modeSparseDir is the mode of a directory entry in a sparse index
+	modeSparseDir = 0o040000
+
+	// headerSize is the size of the signature, version and entry count
+	headerSize = 12
+	// statSize is the size of the cached stat data that starts every entry
+	statSize = 40
+)
+
+// Entry is a path recorded in the index.
+type Entry struct {
+	// Path is relative to the root of the working tree and uses forward slashes. Directory
+	// entries of a sparse index end with a slash.
+	Path string
+	// Mode is the git file mode, such as 0100644, 0100755, 0120000 for symlinks or 0160000 for
+	// submodules.
+	Mode uint32
+	// Size is the size of the file in the working tree when it was last staged, truncated to 32 bits.
+	Size uint32
+	// Hash is the object name of the staged content.
+	Hash []byte
+	// Stage is 0 for normal entries and 1 to 3 for the sides of an unresolved merge conflict.
+	Stage int
+	// SkipWorktree is set for paths outside a sparse checkout.
+	SkipWorktree bool
+	// IntentToAdd is set for paths added with `git add -N`.
+	IntentToAdd bool
+}
+
+// Index is the parsed content of a git index file.
+type Index struct {
+	// Version is the index format version, 2, 3 or 4.
+	Version int
+	// Entries holds the entries sorted by path, as git stores them. A path with merge conflicts
+	// has one entry per stage.
+	Entries []Entry
+
+	// sparse is set if any entry is a sparse directory
+	sparse bool
+}
+
+// ReadFile reads the index file at path, typically ".git/index".
+func ReadFile(path string) (*Index, error) {
+	if path == "" {
+		return nil, errors.New("index path cannot be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index %q: %w", path, err)
+	}
+	index, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index %q: %w", path, err)
+	}
+	return index, nil
+}
+
+// Read reads an index from r.
+func Read(r io.Reader) (*Index, error) {
+	if r == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses the content of an index file. The trailing checksum is verified unless it was
+// disabled with index.skipHash, and its size determines whether object names are SHA-1 or SHA-256.
+// Extensions are skipped.
+func Parse(data []byte) (*Index, error) {
+	if len(data) < headerSize || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("%w: missing signature", ErrInvalidIndex)
+	}
+
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidIndex, version)
+	}
+
+	hashSize, err := checksumSize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{data: data[:len(data)-hashSize], offset: headerSize, version: int(version), hashSize: hashSize}
+	count := binary.BigEndian.Uint32(data[8:12])
+	index := &Index{Version: int(version)}
+	for i := uint32(0); i < count; i++ {
+		entry, err := p.entry()
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %v", ErrInvalidIndex, i, err)
+		}
+		if entry.Mode == modeSparseDir {
+			index.sparse = true
+		}
+		index.Entries = append(index.Entries, entry)
+	}
+	return index, nil
+}
+
+// checksumSize returns the size of the trailing checksum, which is also the size of every object
+// name in the index.
+func checksumSize(data []byte) (int, error) {
+	if len(data) >= headerSize+sha1.Size {
+		sha1Sum := sha1.Sum(data[:len(data)-sha1.Size])
+		if bytes.Equal(data[len(data)-sha1.Size:], sha1Sum[:]) {
+			return sha1.Size, nil
+		}
+	}
+	if len(data) >= headerSize+sha256.Size {
+		sha256Sum := sha256.Sum256(data[:len(data)-sha256.Size])
+		if bytes.Equal(data[len(data)-sha256.Size:], sha256Sum[:]) {
+			return sha256.Size, nil
+		}
+	}
+
+	// With index.skipHash the checksum is all zeros, which does not reveal the hash size
+	if len(data) >= headerSize+sha1.Size && allZero(data[len(data)-sha1.Size:]) {
+		return sha1.Size, nil
+	}
+	return 0, fmt.Errorf("%w: checksum mismatch", ErrInvalidIndex)
+}
+
+// allZero reports whether every byte of b is zero.
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parser reads entries from the body of an index.
+type parser struct {
+	data     []byte
+	offset   int
+	version  int
+	hashSize int
+	// previous is the path of the last entry, which version 4 paths are relative to
+	previous string
+}
+
+// entry parses the entry at the current offset.
+func (p *parser) entry() (Entry, error) {
+	start := p.offset
+	fixed := statSize + p.hashSize + 2
+	if len(p.data)-start < fixed {
+		return Entry{}, errors.New("truncated entry")
+	}
+
+	entry := Entry{
+		Mode: binary.BigEndian.Uint32(p.data[start+24:]),
+		Size: binary.BigEndian.Uint32(p.data[start+36:]),
+		Hash: append([]byte(nil), p.data[start+statSize:start+statSize+p.hashSize]...),
+	}
+	flags := binary.BigEndian.Uint16(p.data[start+statSize+p.hashSize:])
+	entry.Stage = int(flags>>flagStageShift) & 3
+	p.offset = start + fixed
+
+	if flags&flagExtended != 0 {
+		if p.version < 3 {
+			return Entry{}, errors.New("extended flags in version 2 index")
+		}
+		if len(p.data)-p.offset < 2 {
+			return Entry{}, errors.New("truncated entry")
+		}
+		extended := binary.BigEndian.Uint16(p.data[p.offset:])
+		entry.SkipWorktree = extended&extendedSkipWorktree != 0
+		entry.IntentToAdd = extended&extendedIntentToAdd != 0
+		p.offset += 2
+	}
+
+	var err error
+	if p.version == 4 {
+		entry.Path, err = p.compressedPath()
+	} else {
+		entry.Path, err = p.paddedPath(start, int(flags&flagNameMask))
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	p.previous = entry.Path
+	return entry, nil
+}
+
+// paddedPath reads a NUL-terminated path followed by the padding that aligns entries of version 2
+// and 3 indexes to 8 bytes.
+func (p *parser) paddedPath(start, length int) (string, error) {
+	end := bytes.IndexByte(p.data[p.offset:], 0)
+	if end < 0 {
+		return "", errors.New("unterminated path")
+	}
+	if length < flagNameMask && end != length {
+		return "", fmt.Errorf("path length %d does not match recorded length %d", end, length)
+	}
+	path := string(p.data[p.offset : p.offset+end])
+
+	// Entries are padded with 1 to 8 NUL bytes to a multiple of 8
+	size := (p.offset - start + end + 8) &^ 7
+	if start+size > len(p.data) {
+		return "", errors.New("truncated entry")
+	}
+	p.offset = start + size
+	return path, nil
+}
+
+// compressedPath reads a version 4 path: the number of bytes to remove from the end of the
+// previous path, followed by the NUL-terminated suffix to append.
+func (p *parser) compressedPath() (string, error) {
+	strip, err := p.varint()
+	if err != nil {
+		return "", err
+	}
+	if strip > uint64(len(p.previous)) {
+		return "", fmt.Errorf("prefix length %d exceeds previous path", strip)
+	}
+
+	end := bytes.IndexByte(p.data[p.offset:], 0)
+	if end < 0 {
+		return "", errors.New("unterminated path")
+	}
+	path := p.previous[:len(p.previous)-int(strip)] + string(p.data[p.offset:p.offset+end])
+	p.offset += end + 1
+	return path, nil
+}
+
+// varint reads git's offset encoding, in which every continuation byte also adds one, so that
+// each value has a single encoding.
+func (p *parser) varint() (uint64, error) {
+	var value uint64
+	for i := 0; ; i++ {
+		if p.offset >= len(p.data) || i > 9 {
+			return 0, errors.New("invalid varint")
+		}
+		c := p.data[p.offset]
+		p.offset++
+		if i > 0 {
+			value++
+		}
+		value = value<<7 | uint64(c&0x7f)
+		if c&0x80 == 0 {
+			return value, nil
+		}
+	}
+}
+
+// Paths returns the tracked paths in sorted order, each once regardless of merge stages.
+func (idx *Index) Paths() []string {
+	var paths []string
+	for _, entry := range idx.Entries {
+		if n := len(paths); n > 0 && paths[n-1] == entry.Path {
+			continue
+		}
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+// Tracked reports whether path is tracked: either it is in the index itself, or it is a directory
+// that contains tracked paths. Such directories are not ignored by git even if a pattern matches
+// them, because git never ignores tracked content. The path is relative to the root of the
+// working tree and uses forward slashes.
+func (idx *Index) Tracked(path string) bool {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return len(idx.Entries) > 0
+	}
+
+	// Entries below a directory sort right after the directory prefix itself
+	i := idx.search(path)
+	if i < len(idx.Entries) && idx.Entries[i].Path == path {
+		return true
+	}
+	if i = idx.search(path + "/"); i < len(idx.Entries) && strings.HasPrefix(idx.Entries[i].Path, path+"/") {
+		return true
+	}
+
+	if idx.sparse {
+		// Paths below a sparse directory entry are tracked but not listed
+		for dir := path; ; {
+			slash := strings.LastIndexByte(dir, '/')
+			if slash < 0 {
+				break
+			}
+			dir = dir[:slash]
+			if i = idx.search(dir + "/"); i < len(idx.Entries) && idx.Entries[i].Path == dir+"/" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// search returns the index of the first entry whose path is not less than path.
+func (idx *Index) search(path string) int {
+	return sort.Search(len(idx.Entries), func(i int) bool {
+		return idx.Entries[i].Path >= path
+	})
+}
@@ -0,0 +1,228 @@
+package gitindex
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fixtures in testdata were written by git 2.39 with `git update-index --index-version`.
+// index-v2 holds the paths of fixturePaths except added.txt, and index-v3 and index-v4 also hold
+// added.txt, added with `git add -N`, which requires extended flags.
+var fixturePaths = []string{
+	"a.txt",
+	"added.txt",
+	"build/keep.txt",
+	"dir/b.go",
+	"dir/sub/c.go",
+	"dir/sub/d.go",
+	"with space.txt",
+	"zzz",
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		file    string
+		version int
+		paths   []string
+	}{
+		{"index-v2", 2, append([]string{"a.txt"}, fixturePaths[2:]...)},
+		{"index-v3", 3, fixturePaths},
+		{"index-v4", 4, fixturePaths},
+		{"index-sha256", 2, []string{"d/f", "top"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			index, err := ReadFile(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if index.Version != test.version {
+				t.Errorf("Expected version %d, got %d", test.version, index.Version)
+			}
+			if paths := index.Paths(); !reflect.DeepEqual(paths, test.paths) {
+				t.Errorf("Expected paths %v, got %v", test.paths, paths)
+			}
+
+			hashSize := 20
+			if test.file == "index-sha256" {
+				hashSize = 32
+			}
+			for _, entry := range index.Entries {
+				if entry.Mode != 0o100644 || entry.Stage != 0 || len(entry.Hash) != hashSize {
+					t.Errorf("Unexpected entry %+v", entry)
+				}
+				if entry.IntentToAdd != (entry.Path == "added.txt") {
+					t.Errorf("Expected IntentToAdd only for added.txt, got %+v", entry)
+				}
+			}
+		})
+	}
+}
+
+func TestTracked(t *testing.T) {
+	index, err := ReadFile(filepath.Join("testdata", "index-v4"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"a.txt", true},
+		{"dir/sub/c.go", true},
+		{"dir", true},
+		{"dir/sub", true},
+		{"dir/sub/", true},
+		{"build", true},
+		{"build/out.o", false},
+		{"di", false},
+		{"dir/b", false},
+		{"a.txt/x", false},
+		{"missing.txt", false},
+		{"zzzz", false},
+	}
+
+	for _, test := range tests {
+		if tracked := index.Tracked(test.path); tracked != test.expected {
+			t.Errorf("Expected Tracked(%q) = %v, got %v", test.path, test.expected, tracked)
+		}
+	}
+}
+
+// testEntry describes an entry for buildIndex.
+type testEntry struct {
+	path     string
+	mode     uint32
+	extended uint16
+}
+
+// buildIndex encodes a version 2 or 3 index with SHA-1 object names.
+func buildIndex(version uint32, entries []testEntry, skipHash bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("DIRC")
+	binary.Write(&buf, binary.BigEndian, version)
+	binary.Write(&buf, binary.BigEndian, uint32(len(entries)))
+	for _, entry := range entries {
+		start := buf.Len()
+		buf.Write(make([]byte, 24))
+		binary.Write(&buf, binary.BigEndian, entry.mode)
+		buf.Write(make([]byte, 12+20))
+		flags := uint16(flagNameMask)
+		if len(entry.path) < flagNameMask {
+			flags = uint16(len(entry.path))
+		}
+		if entry.extended != 0 {
+			flags |= flagExtended
+		}
+		binary.Write(&buf, binary.BigEndian, flags)
+		if entry.extended != 0 {
+			binary.Write(&buf, binary.BigEndian, entry.extended)
+		}
+		buf.WriteString(entry.path)
+		buf.Write(make([]byte, 8-(buf.Len()-start)%8))
+	}
+	if skipHash {
+		buf.Write(make([]byte, sha1.Size))
+	} else {
+		sum := sha1.Sum(buf.Bytes())
+		buf.Write(sum[:])
+	}
+	return buf.Bytes()
+}
+
+func TestParseSparseAndSkipHash(t *testing.T) {
+	data := buildIndex(3, []testEntry{
+		{path: "docs/", mode: modeSparseDir, extended: extendedSkipWorktree},
+		{path: "src/main.go", mode: 0o100644},
+	}, true)
+
+	index, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !index.Entries[0].SkipWorktree {
+		t.Errorf("Expected sparse directory to be skip-worktree, got %+v", index.Entries[0])
+	}
+
+	for _, path := range []string{"docs", "docs/guide/intro.md", "src", "src/main.go"} {
+		if !index.Tracked(path) {
+			t.Errorf("Expected %q to be tracked", path)
+		}
+	}
+	for _, path := range []string{"doc", "src/other.go"} {
+		if index.Tracked(path) {
+			t.Errorf("Expected %q not to be tracked", path)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := buildIndex(2, []testEntry{{path: "file.txt", mode: 0o100644}}, false)
+
+	corrupt := append([]byte(nil), valid...)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	badVersion := append([]byte(nil), valid...)
+	badVersion[7] = 5
+
+	extendedV2 := buildIndex(2, []testEntry{{path: "file.txt", mode: 0o100644, extended: extendedIntentToAdd}}, false)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"signature", []byte("DIRX\x00\x00\x00\x02\x00\x00\x00\x00")},
+		{"version", badVersion},
+		{"checksum", corrupt},
+		{"truncated", append(append([]byte(nil), valid[:30]...), make([]byte, 20)...)},
+		{"extended flags in version 2", extendedV2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Parse(test.data); !errors.Is(err, ErrInvalidIndex) {
+				t.Errorf("Expected ErrInvalidIndex, got %v", err)
+			}
+		})
+	}
+
+	if _, err := Parse(valid); err != nil {
+		t.Errorf("Expected valid index to parse, got %v", err)
+	}
+	if _, err := Read(bytes.NewReader(valid)); err != nil {
+		t.Errorf("Expected valid index to read, got %v", err)
+	}
+	if _, err := Read(nil); err == nil {
+		t.Error("Expected error for nil reader")
+	}
+	if _, err := ReadFile(""); err == nil {
+		t.Error("Expected error for empty path")
+	}
+	if _, err := ReadFile(filepath.Join(t.TempDir(), "index")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestParseLongPath(t *testing.T) {
+	long := strings.Repeat("d/", 2100) + "file"
+	data := buildIndex(2, []testEntry{{path: long, mode: 0o100644}}, false)
+
+	// The recorded length saturates at 0xfff for long paths
+	index, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if index.Entries[0].Path != long {
+		t.Errorf("Expected path of length %d, got %d", len(long), len(index.Entries[0].Path))
+	}
+}
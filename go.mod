@@ -1,3 +1,5 @@
 module github.com/codeglyph/go-dotignore
 
 go 1.20
+
+require golang.org/x/text v0.14.0
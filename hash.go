@@ -0,0 +1,151 @@
+package dotignore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// HashOptions controls HashTree.
+type HashOptions struct {
+	// IncludeMode makes permission bits part of the digests, so that for example making a file
+	// executable changes the hash.
+	IncludeMode bool
+}
+
+// HashEntry describes one file, directory or symlink hashed by HashTree.
+type HashEntry struct {
+	// Path is relative to the root and uses forward slashes.
+	Path string
+	// Mode holds the type and permission bits of the entry.
+	Mode fs.FileMode
+	// Size is the size in bytes of a file, or the length of a symlink target.
+	Size int64
+	// Digest is the hex-encoded SHA-256 digest of the entry.
+	Digest string
+}
+
+// String formats the entry as a manifest line: digest, type, and path.
+func (e HashEntry) String() string {
+	return e.Digest + " " + hashKind(e.Mode) + " " + e.Path
+}
+
+// HashTree computes a deterministic Merkle-style SHA-256 digest of the files, directories and
+// symlinks under root that matcher does not ignore. Ignored directories and the .git directory are
+// skipped entirely, as are sockets, devices and named pipes.
+//
+// A file is hashed by its contents and a symlink by its target. A directory is hashed over its
+// children sorted by name, each contributing its type ("file", "dir" or "symlink"), its permission
+// bits as four octal digits if IncludeMode is set, its name, a NUL byte and its raw digest. Empty
+// directories are included. Modification times and ownership never affect the result.
+//
+// It returns the hex-encoded digest of root together with a manifest of every entry below it,
+// sorted by path.
+func HashTree(root string, matcher *PatternMatcher, opts HashOptions) (string, []HashEntry, error) {
+	if root == "" {
+		return "", nil, errors.New("root cannot be empty")
+	}
+	if matcher == nil {
+		return "", nil, errors.New("matcher cannot be nil")
+	}
+
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash %q: %w", root, err)
+	}
+	if !rootInfo.IsDir() {
+		return "", nil, fmt.Errorf("failed to hash %q: not a directory", root)
+	}
+
+	// Entries in walk order, where every directory comes before its contents, and the indices of
+	// the children of every directory, in name order.
+	var entries []HashEntry
+	children := make(map[string][]int)
+
+	err = walkIncluded(root, matcher, func(rel string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := HashEntry{Path: rel, Mode: info.Mode()}
+		fullPath := filepath.Join(root, filepath.FromSlash(rel))
+
+		switch {
+		case d.IsDir():
+			// Hashed once all of its children are known
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(fullPath)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256([]byte(filepath.ToSlash(target)))
+			entry.Size = int64(len(target))
+			entry.Digest = hex.EncodeToString(sum[:])
+		case d.Type().IsRegular():
+			sum, err := fileDigest(fullPath)
+			if err != nil {
+				return err
+			}
+			entry.Size = info.Size()
+			entry.Digest = hex.EncodeToString(sum)
+		default:
+			return nil
+		}
+
+		parent := path.Dir(rel)
+		children[parent] = append(children[parent], len(entries))
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash %q: %w", root, err)
+	}
+
+	// Directories come before their contents, so walking backwards sees every child first
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Mode.IsDir() {
+			entries[i].Digest = directoryDigest(entries, children[entries[i].Path], opts)
+		}
+	}
+	rootDigest := directoryDigest(entries, children["."], opts)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return rootDigest, entries, nil
+}
+
+// directoryDigest hashes the given children of a directory, which must already be hashed.
+func directoryDigest(entries []HashEntry, children []int, opts HashOptions) string {
+	hash := sha256.New()
+	for _, index := range children {
+		entry := entries[index]
+		raw, _ := hex.DecodeString(entry.Digest)
+
+		hash.Write([]byte(hashKind(entry.Mode)))
+		if opts.IncludeMode {
+			fmt.Fprintf(hash, " %04o", entry.Mode.Perm())
+		}
+		hash.Write([]byte(" " + path.Base(entry.Path) + "\x00"))
+		hash.Write(raw)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// hashKind names the type of an entry in directory digests and manifests.
+func hashKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "file"
+	}
+}
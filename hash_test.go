@@ -0,0 +1,146 @@
+package dotignore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func hashTree(t *testing.T, root string, matcher *PatternMatcher, opts HashOptions) (string, []HashEntry) {
+	t.Helper()
+	digest, manifest, err := HashTree(root, matcher, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return digest, manifest
+}
+
+func TestHashTree(t *testing.T) {
+	matcher := archiveMatcher(t)
+	root := createArchiveTree(t)
+
+	digest, manifest := hashTree(t, root, matcher, HashOptions{})
+
+	var paths []string
+	for _, entry := range manifest {
+		paths = append(paths, entry.Path)
+	}
+	expectedPaths := []string{"README.md", "bin", "bin/run.sh", "link.md", "src", "src/main.go"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected manifest paths %v, got %v", expectedPaths, paths)
+	}
+
+	sum := sha256.Sum256([]byte("README.md"))
+	readme := manifest[0]
+	if readme.Digest != hex.EncodeToString(sum[:]) || readme.Size != int64(len("README.md")) {
+		t.Errorf("Expected README.md digest of its contents, got %+v", readme)
+	}
+	if manifest[3].Mode&os.ModeSymlink == 0 {
+		t.Errorf("Expected link.md to be a symlink, got %v", manifest[3].Mode)
+	}
+
+	// Building the same tree elsewhere yields the same digest
+	other := createArchiveTree(t)
+	if otherDigest, _ := hashTree(t, other, matcher, HashOptions{}); otherDigest != digest {
+		t.Errorf("Expected identical trees to hash equally, got %s and %s", digest, otherDigest)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(root string) error
+		opts    HashOptions
+		changed bool
+	}{
+		{"ignored file", func(root string) error {
+			return os.WriteFile(filepath.Join(root, "build", "out.o"), []byte("changed"), 0644)
+		}, HashOptions{}, false},
+		{"new ignored file", func(root string) error {
+			return os.WriteFile(filepath.Join(root, "new.log"), nil, 0644)
+		}, HashOptions{}, false},
+		{"modification time", func(root string) error {
+			past := time.Unix(0, 0)
+			return os.Chtimes(filepath.Join(root, "README.md"), past, past)
+		}, HashOptions{}, false},
+		{"mode without IncludeMode", func(root string) error {
+			return os.Chmod(filepath.Join(root, "README.md"), 0600)
+		}, HashOptions{}, false},
+		{"mode with IncludeMode", func(root string) error {
+			return os.Chmod(filepath.Join(root, "README.md"), 0600)
+		}, HashOptions{IncludeMode: true}, true},
+		{"content", func(root string) error {
+			return os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main"), 0644)
+		}, HashOptions{}, true},
+		{"empty directory", func(root string) error {
+			return os.Mkdir(filepath.Join(root, "empty"), 0755)
+		}, HashOptions{}, true},
+		{"rename", func(root string) error {
+			return os.Rename(filepath.Join(root, "src", "main.go"), filepath.Join(root, "src", "app.go"))
+		}, HashOptions{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := createArchiveTree(t)
+			before, _ := hashTree(t, root, matcher, test.opts)
+			if err := test.modify(root); err != nil {
+				t.Fatalf("Failed to modify tree: %v", err)
+			}
+			after, _ := hashTree(t, root, matcher, test.opts)
+			if (before != after) != test.changed {
+				t.Errorf("Expected changed=%v, got digests %s and %s", test.changed, before, after)
+			}
+		})
+	}
+}
+
+func TestHashTreeDigestFormat(t *testing.T) {
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	empty := sha256.Sum256(nil)
+	if digest, manifest := hashTree(t, t.TempDir(), matcher, HashOptions{}); digest != hex.EncodeToString(empty[:]) || len(manifest) != 0 {
+		t.Errorf("Expected empty tree digest %x, got %s with %v", empty, digest, manifest)
+	}
+
+	root := createTree(t, "a")
+	if err := os.Chmod(filepath.Join(root, "a"), 0644); err != nil {
+		t.Fatalf("Failed to change mode: %v", err)
+	}
+	file := sha256.Sum256([]byte("a"))
+	expected := sha256.Sum256(append([]byte("file 0644 a\x00"), file[:]...))
+
+	digest, manifest := hashTree(t, root, matcher, HashOptions{IncludeMode: true})
+	if digest != hex.EncodeToString(expected[:]) {
+		t.Errorf("Expected digest %x, got %s", expected, digest)
+	}
+	if line := manifest[0].String(); line != hex.EncodeToString(file[:])+" file a" {
+		t.Errorf("Expected manifest line for a, got %q", line)
+	}
+}
+
+func TestHashTreeErrors(t *testing.T) {
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	root := createTree(t, "file.txt")
+
+	if _, _, err := HashTree("", matcher, HashOptions{}); err == nil {
+		t.Error("Expected error for empty root")
+	}
+	if _, _, err := HashTree(root, nil, HashOptions{}); err == nil {
+		t.Error("Expected error for nil matcher")
+	}
+	if _, _, err := HashTree(filepath.Join(root, "file.txt"), matcher, HashOptions{}); err == nil {
+		t.Error("Expected error for non-directory root")
+	}
+	if _, _, err := HashTree(filepath.Join(root, "missing"), matcher, HashOptions{}); err == nil {
+		t.Error("Expected error for missing root")
+	}
+}
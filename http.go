@@ -0,0 +1,149 @@
+package dotignore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FilterHTTPFileSystem returns an http.FileSystem that serves fsys without the paths matcher
+// ignores. Ignored paths, and everything below ignored directories, fail to open with
+// fs.ErrNotExist, which http.FileServer reports as 404 Not Found, and directory listings omit
+// them. Paths are matched relative to the root of fsys, using the names as the directory listings
+// spell them, so that a request for "/.ENV" cannot open ".env" on a file system that ignores case.
+func FilterHTTPFileSystem(fsys http.FileSystem, matcher *PatternMatcher) http.FileSystem {
+	f := &filteredHTTPFileSystem{fsys: fsys}
+	f.pathFilter = pathFilter{matcher: matcher, exists: f.exists, names: f.names}
+	return f
+}
+
+// FileServer is http.FileServer for root with the paths matcher ignores hidden.
+func FileServer(root http.FileSystem, matcher *PatternMatcher) http.Handler {
+	return http.FileServer(FilterHTTPFileSystem(root, matcher))
+}
+
+// filteredHTTPFileSystem implements FilterHTTPFileSystem.
+type filteredHTTPFileSystem struct {
+	pathFilter
+	fsys http.FileSystem
+}
+
+// Open implements http.FileSystem.
+func (f *filteredHTTPFileSystem) Open(name string) (http.File, error) {
+	// Match the way http.Dir resolves names, so both sides agree on the path being opened
+	rel := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if rel == "" {
+		rel = "."
+	}
+
+	// Match the names as they are spelled on disk, since on a file system that ignores case,
+	// "/.ENV" opens the file ".env"
+	rel, err := f.realPath(rel)
+	if err != nil {
+		return nil, notExist("open", name, nil)
+	}
+
+	if hidden, err := f.ancestorIgnored(rel); err != nil || hidden {
+		return nil, notExist("open", name, err)
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if hidden, err := f.ignored(rel, info.IsDir()); err != nil || hidden {
+		file.Close()
+		return nil, notExist("open", name, err)
+	}
+
+	if info.IsDir() {
+		return &filteredHTTPDir{File: file, filter: f.pathFilter, name: rel}, nil
+	}
+	return file, nil
+}
+
+// exists reports whether name exists in the underlying file system.
+func (f *filteredHTTPFileSystem) exists(name string) bool {
+	file, err := f.fsys.Open("/" + name)
+	if err != nil {
+		return false
+	}
+	file.Close()
+	return true
+}
+
+// names lists the names in directory dir of the underlying file system.
+func (f *filteredHTTPFileSystem) names(dir string) ([]string, error) {
+	d, err := f.fsys.Open("/" + dir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	infos, err := d.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, nil
+}
+
+// filteredHTTPDir is an open directory whose listing omits ignored entries.
+type filteredHTTPDir struct {
+	http.File
+	filter pathFilter
+	name   string
+}
+
+// Readdir implements http.File, returning up to count entries that are not ignored.
+func (d *filteredHTTPDir) Readdir(count int) ([]fs.FileInfo, error) {
+	if count <= 0 {
+		infos, err := d.File.Readdir(count)
+		if err != nil {
+			return nil, err
+		}
+		return d.filterInfos(infos)
+	}
+
+	var result []fs.FileInfo
+	for len(result) < count {
+		infos, err := d.File.Readdir(count - len(result))
+		kept, filterErr := d.filterInfos(infos)
+		if filterErr != nil {
+			return result, filterErr
+		}
+		result = append(result, kept...)
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(result) > 0 {
+				return result, nil
+			}
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// filterInfos removes the ignored entries of the directory.
+func (d *filteredHTTPDir) filterInfos(infos []fs.FileInfo) ([]fs.FileInfo, error) {
+	kept := infos[:0]
+	for _, info := range infos {
+		hidden, err := d.filter.ignored(path.Join(d.name, info.Name()), info.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		if !hidden {
+			kept = append(kept, info)
+		}
+	}
+	return kept, nil
+}
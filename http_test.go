@@ -0,0 +1,260 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	root := createTree(t, "home.html", ".env", ".git/config", "static/app.js", "static/app.js.map", "logs/access.log")
+	matcher, err := NewPatternMatcher([]string{".env", ".git/", "*.map", "logs/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	server := httptest.NewServer(FileServer(http.Dir(root), matcher))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestFileServer(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/home.html", http.StatusOK},
+		{"/static/app.js", http.StatusOK},
+		{"/.env", http.StatusNotFound},
+		{"/.git/config", http.StatusNotFound},
+		{"/.git/", http.StatusNotFound},
+		{"/static/app.js.map", http.StatusNotFound},
+		{"/logs/access.log", http.StatusNotFound},
+		{"/static/../.env", http.StatusNotFound},
+		{"/%2eenv", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status, _ := get(t, server.URL+tt.path)
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+		})
+	}
+}
+
+func TestFileServerDirectoryListing(t *testing.T) {
+	server := newTestServer(t)
+
+	_, root := get(t, server.URL+"/")
+	for _, hidden := range []string{".env", ".git", "logs"} {
+		if strings.Contains(root, `href="`+hidden) {
+			t.Errorf("Expected %s to be omitted from the root listing:\n%s", hidden, root)
+		}
+	}
+	if !strings.Contains(root, `href="home.html"`) || !strings.Contains(root, `href="static/"`) {
+		t.Errorf("Expected visible entries in the root listing:\n%s", root)
+	}
+
+	_, static := get(t, server.URL+"/static/")
+	if strings.Contains(static, "app.js.map") || !strings.Contains(static, `href="app.js"`) {
+		t.Errorf("Unexpected static listing:\n%s", static)
+	}
+}
+
+// caseInsensitiveDir is an http.Dir that opens names regardless of case, as on macOS and Windows.
+type caseInsensitiveDir string
+
+func (d caseInsensitiveDir) Open(name string) (http.File, error) {
+	real := "/"
+	for _, part := range strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(string(d), filepath.FromSlash(real)))
+		if err != nil {
+			return nil, err
+		}
+		found := ""
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), part) {
+				found = entry.Name()
+				break
+			}
+		}
+		if found == "" {
+			return nil, fs.ErrNotExist
+		}
+		real = path.Join(real, found)
+	}
+	return http.Dir(d).Open(real)
+}
+
+func TestFileServerCaseInsensitive(t *testing.T) {
+	root := createTree(t, "home.html", ".env", ".git/config", "static/app.js", "static/app.js.map", "logs/access.log")
+	matcher, err := NewPatternMatcher([]string{".env", ".git/", "*.map", "logs/"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	server := httptest.NewServer(FileServer(caseInsensitiveDir(root), matcher))
+	defer server.Close()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/HOME.html", http.StatusOK},
+		{"/Static/app.js", http.StatusOK},
+		{"/.ENV", http.StatusNotFound},
+		{"/.Git/config", http.StatusNotFound},
+		{"/LOGS/access.log", http.StatusNotFound},
+		{"/static/APP.js.map", http.StatusNotFound},
+		{"/missing.txt", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		status, _ := get(t, server.URL+tt.path)
+		if status != tt.status {
+			t.Errorf("GET %s: Expected status %d, got %d", tt.path, tt.status, status)
+		}
+	}
+}
+
+func TestFilterHTTPFileSystemReaddirBatches(t *testing.T) {
+	root := createTree(t, "a.txt", "b.log", "c.log", "d.txt")
+	matcher, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	dir, err := FilterHTTPFileSystem(http.Dir(root), matcher).Open("/")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer dir.Close()
+
+	var names []string
+	for {
+		infos, err := dir.Readdir(1)
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if len(names) != 2 || strings.Contains(strings.Join(names, ","), "log") {
+		t.Errorf("Expected only the .txt files, got %v", names)
+	}
+}
+
+// countingHTTPFileSystem counts the directory listings read from an http.FileSystem.
+type countingHTTPFileSystem struct {
+	http.FileSystem
+	readdirs *int
+	// err, if set, is returned by Readdir together with the entries
+	err error
+}
+
+func (c countingHTTPFileSystem) Open(name string) (http.File, error) {
+	file, err := c.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return countingHTTPFile{File: file, fs: c}, nil
+}
+
+type countingHTTPFile struct {
+	http.File
+	fs countingHTTPFileSystem
+}
+
+func (f countingHTTPFile) Readdir(count int) ([]fs.FileInfo, error) {
+	*f.fs.readdirs++
+	infos, err := f.File.Readdir(count)
+	if f.fs.err != nil {
+		return infos, f.fs.err
+	}
+	return infos, err
+}
+
+func TestFilterHTTPFileSystemListsOnlyOnMismatch(t *testing.T) {
+	files := []string{"home.html", ".env", "static/app.js"}
+	for i := 0; i < 100; i++ {
+		files = append(files, fmt.Sprintf("static/file%d.js", i))
+	}
+	root := createTree(t, files...)
+	matcher, err := NewPatternMatcher([]string{".env"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	readdirs := 0
+	fsys := FilterHTTPFileSystem(countingHTTPFileSystem{FileSystem: http.Dir(root), readdirs: &readdirs}, matcher)
+	for _, name := range []string{"/home.html", "/static/app.js", "/static/file42.js"} {
+		file, err := fsys.Open(name)
+		if err != nil {
+			t.Fatalf("Open(%q) failed: %v", name, err)
+		}
+		file.Close()
+	}
+	if readdirs != 0 {
+		t.Errorf("Expected no directory listings for names spelled as on disk, got %d", readdirs)
+	}
+
+	// Only a name with another spelling on disk needs the listing of its directory
+	if _, err := fsys.Open("/static/APP.js"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for /static/APP.js, got %v", err)
+	}
+	if readdirs != 1 {
+		t.Errorf("Expected one directory listing, got %d", readdirs)
+	}
+}
+
+func TestFilterHTTPFileSystemReaddirError(t *testing.T) {
+	root := createTree(t, "a.txt", "b.txt")
+	matcher, err := NewPatternMatcher(nil)
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	readdirs := 0
+	diskErr := errors.New("disk error")
+	dir, err := FilterHTTPFileSystem(countingHTTPFileSystem{FileSystem: http.Dir(root), readdirs: &readdirs, err: diskErr}, matcher).Open("/")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer dir.Close()
+
+	if infos, err := dir.Readdir(5); !errors.Is(err, diskErr) || len(infos) != 2 {
+		t.Errorf("Expected both entries and the disk error, got %d entries and %v", len(infos), err)
+	}
+}
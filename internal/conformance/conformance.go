@@ -0,0 +1,192 @@
+// Package conformance reads and writes the golden files of the git conformance suite.
+//
+// Each case is a text file made of sections, each introduced by a "-- name --" line:
+//
+//	-- gitignore --
+//	*.log
+//	!keep.log
+//	-- paths --
+//	a.log
+//	keep.log
+//	dir/
+//	dir/b.log
+//	-- ignored --
+//	a.log
+//	dir/b.log
+//
+// The gitignore section is the exact content of the ignore file at the root of the tree. The
+// paths section lists the paths to create and check, relative to the root; a trailing slash marks
+// a directory. The ignored section holds the paths that `git check-ignore` reports as ignored. It
+// is generated by running git and must not be edited by hand.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Section names used in case files.
+const (
+	SectionGitignore = "gitignore"
+	SectionPaths     = "paths"
+	SectionIgnored   = "ignored"
+)
+
+// Case is a single conformance case.
+type Case struct {
+	// Name is the file name of the case without its extension.
+	Name string
+	// Gitignore is the content of the ignore file.
+	Gitignore string
+	// Paths holds the paths to check, with a trailing slash for directories.
+	Paths []string
+	// Ignored holds the paths git reports as ignored, in the order of Paths.
+	Ignored []string
+}
+
+// Patterns returns the lines of the ignore file.
+func (c Case) Patterns() []string {
+	return strings.Split(strings.TrimSuffix(c.Gitignore, "\n"), "\n")
+}
+
+// IsIgnored reports whether git reports path as ignored.
+func (c Case) IsIgnored(path string) bool {
+	for _, ignored := range c.Ignored {
+		if ignored == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses the content of a case file.
+func Parse(name string, data []byte) (Case, error) {
+	c := Case{Name: name}
+	sections := make(map[string]*strings.Builder)
+	var current *strings.Builder
+
+	lines := strings.SplitAfter(string(data), "\n")
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") && len(trimmed) > 6 {
+			section := trimmed[3 : len(trimmed)-3]
+			if _, ok := sections[section]; ok {
+				return Case{}, fmt.Errorf("case %s: duplicate section %q", name, section)
+			}
+			current = &strings.Builder{}
+			sections[section] = current
+			continue
+		}
+		if current == nil {
+			if strings.TrimSpace(line) != "" {
+				return Case{}, fmt.Errorf("case %s: content before the first section", name)
+			}
+			continue
+		}
+		current.WriteString(line)
+	}
+
+	gitignore, ok := sections[SectionGitignore]
+	if !ok {
+		return Case{}, fmt.Errorf("case %s: missing %q section", name, SectionGitignore)
+	}
+	c.Gitignore = gitignore.String()
+
+	paths, ok := sections[SectionPaths]
+	if !ok {
+		return Case{}, fmt.Errorf("case %s: missing %q section", name, SectionPaths)
+	}
+	c.Paths = nonEmptyLines(paths.String())
+	if len(c.Paths) == 0 {
+		return Case{}, fmt.Errorf("case %s: no paths", name)
+	}
+
+	if ignored, ok := sections[SectionIgnored]; ok {
+		c.Ignored = nonEmptyLines(ignored.String())
+	}
+	return c, nil
+}
+
+// nonEmptyLines splits text into lines, dropping empty ones.
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Format returns the content of the case file.
+func (c Case) Format() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("-- " + SectionGitignore + " --\n")
+	buf.WriteString(c.Gitignore)
+	if c.Gitignore != "" && !strings.HasSuffix(c.Gitignore, "\n") {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("-- " + SectionPaths + " --\n")
+	for _, path := range c.Paths {
+		buf.WriteString(path + "\n")
+	}
+	buf.WriteString("-- " + SectionIgnored + " --\n")
+	for _, path := range c.Ignored {
+		buf.WriteString(path + "\n")
+	}
+	return buf.Bytes()
+}
+
+// Load reads every case file with the extension ".txt" in dir, sorted by name.
+func Load(dir string) ([]Case, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no conformance cases in " + dir)
+	}
+	sort.Strings(files)
+
+	var cases []Case
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c, err := Parse(strings.TrimSuffix(filepath.Base(file), ".txt"), data)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// CreateTree creates the ignore file and every path of the case below root.
+func (c Case) CreateTree(root string) error {
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(c.Gitignore), 0644); err != nil {
+		return err
+	}
+	for _, path := range c.Paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if strings.HasSuffix(path, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Command gen regenerates the expected results of the conformance suite by running
+// `git check-ignore` on every case. It is run through go generate from the repository root:
+//
+//	go run ./internal/conformance/gen testdata/conformance
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/internal/conformance"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: gen dir")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string) error {
+	cases, err := conformance.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	version, err := git("", nil, "version")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "GIT_VERSION"), version, 0644); err != nil {
+		return err
+	}
+
+	for _, c := range cases {
+		ignored, err := checkIgnore(c)
+		if err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		c.Ignored = ignored
+		if err := os.WriteFile(filepath.Join(dir, c.Name+".txt"), c.Format(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIgnore creates the tree of a case in a new repository and returns the paths git ignores.
+func checkIgnore(c conformance.Case) ([]string, error) {
+	root, err := os.MkdirTemp("", "conformance")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+
+	if _, err := git(root, nil, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if err := c.CreateTree(root); err != nil {
+		return nil, err
+	}
+
+	var input bytes.Buffer
+	for _, path := range c.Paths {
+		input.WriteString(strings.TrimSuffix(path, "/") + "\x00")
+	}
+	output, err := git(root, &input, "check-ignore", "--no-index", "--stdin", "-z")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// Exit status 1 means that no path is ignored
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]bool)
+	for _, path := range strings.Split(string(output), "\x00") {
+		reported[path] = true
+	}
+	var ignored []string
+	for _, path := range c.Paths {
+		if reported[strings.TrimSuffix(path, "/")] {
+			ignored = append(ignored, path)
+		}
+	}
+	return ignored, nil
+}
+
+// git runs git in dir, isolated from the user's and the system's configuration.
+func git(dir string, stdin *bytes.Buffer, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		}
+		return output, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return output, nil
+}
@@ -0,0 +1,254 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// wildmatch reports whether pattern matches all of name, following git's wildmatch with the
+// WM_PATHNAME flag, which is how git matches gitignore patterns, independently of BuildRegex.
+// It compares characters rather than bytes. Where the library deliberately departs from git it
+// follows the library: an unclosed "[" is an ordinary character instead of failing the match.
+func wildmatch(pattern, name []rune) bool {
+	memo := make(map[[2]int]bool)
+
+	var match func(p, t int) bool
+	match = func(p, t int) bool {
+		key := [2]int{p, t}
+		if result, ok := memo[key]; ok {
+			return result
+		}
+
+		result := false
+		switch {
+		case p == len(pattern):
+			result = t == len(name)
+		case pattern[p] == '*':
+			// A run of stars matches across slashes only when it is two or more stars making up
+			// a whole segment: preceded by the start or a "/", and followed by the end or a "/"
+			end := p
+			for end < len(pattern) && pattern[end] == '*' {
+				end++
+			}
+			rest := pattern[end:]
+			matchSlash := end-p >= 2 && (p == 0 || pattern[p-1] == '/') &&
+				(len(rest) == 0 || rest[0] == '/' || len(rest) > 1 && rest[0] == '\\' && rest[1] == '/')
+			// "**/" also matches no directories at all
+			if matchSlash && len(rest) > 0 && rest[0] == '/' && match(end+1, t) {
+				result = true
+				break
+			}
+			for q := t; !result; q++ {
+				result = match(end, q)
+				if q == len(name) || !matchSlash && name[q] == '/' {
+					break
+				}
+			}
+		case t == len(name):
+			result = false
+		case pattern[p] == '?':
+			result = name[t] != '/' && match(p+1, t+1)
+		case pattern[p] == '[':
+			matched, end, _ := matchClass(pattern, p, name[t])
+			if end < 0 {
+				result = name[t] == '[' && match(p+1, t+1)
+			} else {
+				result = matched && match(end+1, t+1)
+			}
+		case pattern[p] == '\\':
+			// A trailing backslash escapes nothing and never matches
+			result = p+1 < len(pattern) && name[t] == pattern[p+1] && match(p+2, t+1)
+		default:
+			result = name[t] == pattern[p] && match(p+1, t+1)
+		}
+		memo[key] = result
+		return result
+	}
+	return match(0, 0)
+}
+
+// matchClass matches c against the bracket expression opened by the "[" at pattern[start]. It
+// returns the index of the closing "]", or -1 if there is none, and whether the expression holds
+// a reversed range or an unknown class name, which git never matches and BuildRegex rejects.
+// As in git, a bracket expression never matches "/".
+func matchClass(pattern []rune, start int, c rune) (matched bool, end int, invalid bool) {
+	i := start + 1
+	negated := i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^')
+	if negated {
+		i++
+	}
+
+	// prev is the character a following "-" starts a range from; there is none at the start or
+	// after a range or a class name, where "-" stands for itself
+	var prev rune
+	hasPrev := false
+	for first := true; ; first, i = false, i+1 {
+		if i == len(pattern) {
+			return false, -1, false
+		}
+		switch ch := pattern[i]; {
+		case ch == ']' && !first:
+			return matched != negated && c != '/', i, invalid
+		case ch == '\\':
+			i++
+			if i == len(pattern) {
+				return false, -1, false
+			}
+			matched = matched || c == pattern[i]
+			prev, hasPrev = pattern[i], true
+		case ch == '-' && hasPrev && i+1 < len(pattern) && pattern[i+1] != ']':
+			i++
+			hi := pattern[i]
+			if hi == '\\' {
+				i++
+				if i == len(pattern) {
+					return false, -1, false
+				}
+				hi = pattern[i]
+			}
+			invalid = invalid || hi < prev
+			matched = matched || prev <= c && c <= hi
+			hasPrev = false
+		case ch == '[' && i+1 < len(pattern) && pattern[i+1] == ':':
+			k := i + 2
+			for k < len(pattern) && pattern[k] != ']' {
+				k++
+			}
+			if k == len(pattern) {
+				return false, -1, false
+			}
+			if k-1 < i+2 || pattern[k-1] != ':' {
+				// Not a class name, so the "[" stands for itself
+				matched = matched || c == '['
+				prev, hasPrev = '[', true
+				break
+			}
+			if is, ok := posixPredicates[string(pattern[i+2:k-1])]; ok {
+				matched = matched || is(c)
+			} else {
+				invalid = true
+			}
+			hasPrev = false
+			i = k
+		default:
+			matched = matched || c == ch
+			prev, hasPrev = ch, true
+		}
+	}
+}
+
+// posixPredicates holds the classes a bracket expression may name, as git's ASCII-only ctype
+// defines them.
+var posixPredicates = map[string]func(rune) bool{
+	"alnum":  func(c rune) bool { return isAlpha(c) || isDigit(c) },
+	"alpha":  isAlpha,
+	"blank":  func(c rune) bool { return c == ' ' || c == '\t' },
+	"cntrl":  func(c rune) bool { return c < 0x20 || c == 0x7f },
+	"digit":  isDigit,
+	"graph":  func(c rune) bool { return c > ' ' && c < 0x7f },
+	"lower":  func(c rune) bool { return c >= 'a' && c <= 'z' },
+	"print":  func(c rune) bool { return c >= ' ' && c < 0x7f },
+	"punct":  func(c rune) bool { return c > ' ' && c < 0x7f && !isAlpha(c) && !isDigit(c) },
+	"space":  func(c rune) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' },
+	"upper":  func(c rune) bool { return c >= 'A' && c <= 'Z' },
+	"xdigit": func(c rune) bool { return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' },
+}
+
+func isAlpha(c rune) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c rune) bool { return c >= '0' && c <= '9' }
+
+// validGlob reports whether BuildRegex must accept pattern. It rejects empty patterns, invalid
+// UTF-8, and bracket expressions with a reversed range or an unknown class name.
+func validGlob(pattern []rune) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			if _, end, invalid := matchClass(pattern, i, 0); invalid {
+				return false
+			} else if end >= 0 {
+				i = end
+			}
+		}
+	}
+	return len(pattern) > 0
+}
+
+func FuzzBuildRegex(f *testing.F) {
+	seeds := [][2]string{
+		{"*.txt", "notes.txt"},
+		{"**/*.js", "src/app.js"},
+		{"a/**/b", "a/x/y/b"},
+		{"**", "a/b/c"},
+		{"a**b", "a/x/b"},
+		{"***/x", "a/b/x"},
+		{"file?.txt", "file1.txt"},
+		{"[abc].go", "b.go"},
+		{"[!x]y", "ay"},
+		{"[a-c-]z", "-z"},
+		{"[\\]]", "]"},
+		{"[z-a]", "a"},
+		{"[]", "[]"},
+		{"[]abc]", "]"},
+		{"[!]]x", "ax"},
+		{"[a\\]]z", "]z"},
+		{"\\*literal", "*literal"},
+		{"trailing\\", "trailing\\"},
+		{"[unclosed", "[unclosed"},
+		{"(a|b){2}+$^.", "(a|b){2}+$^."},
+		{"日本/*.md", "日本/語.md"},
+		{"a\xffb", "a\xffb"},
+		{"*", "line\nbreak"},
+		{"**", "line\nbreak"},
+		{"a[!b]c", "a/c"},
+		{"[+-0]", "/"},
+		{"[[:space:]]", "\v"},
+		{"[[:punct:]x]", "_"},
+		{"[[:bogus:]]", "b"},
+		{"[[:x]", "[:x]"},
+		{"x**", "x/y"},
+		{"**\\/a", "b/c/a"},
+	}
+	for _, seed := range seeds {
+		f.Add(seed[0], seed[1])
+	}
+
+	// Names that are not valid UTF-8 are skipped: regexp reads their bytes as U+FFFD, and
+	// MatchesBytes tests cover them
+	f.Fuzz(func(t *testing.T, pattern, name string) {
+		if !utf8.ValidString(name) {
+			return
+		}
+		valid := utf8.ValidString(pattern) && validGlob([]rune(pattern))
+		regex, err := BuildRegex(pattern)
+		if valid != (err == nil) {
+			t.Fatalf("BuildRegex(%q) returned %v, reference wildmatch valid: %v", pattern, err, valid)
+		}
+		if err != nil {
+			return
+		}
+
+		if got, want := regex.MatchString(name), wildmatch([]rune(pattern), []rune(name)); got != want {
+			t.Errorf("Pattern %q (regex %q) against %q: regex %v, reference wildmatch %v", pattern, regex, name, got, want)
+		}
+	})
+}
+
+func FuzzNormalizePath(f *testing.F) {
+	for _, seed := range []string{"path/to/file", "path\\to\\file", "./././a", ".//a//", "/", "./", "a/./b/../c"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		normalized := NormalizePath(path)
+		if again := NormalizePath(normalized); again != normalized {
+			t.Errorf("NormalizePath is not idempotent: %q -> %q -> %q", path, normalized, again)
+		}
+		if strings.Contains(normalized, "\\") || strings.Contains(normalized, "//") || strings.HasPrefix(normalized, "./") {
+			t.Errorf("NormalizePath(%q) = %q is not normalized", path, normalized)
+		}
+	})
+}
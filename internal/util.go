@@ -3,46 +3,171 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
 )
 
-// ReadLines reads lines from an io.Reader and strips UTF-8 BOM characters.
+// ErrLineTooLong is reported for lines longer than the maximum given to ReadLinesMax.
+var ErrLineTooLong = errors.New("line too long")
+
+// LineError describes a failure while reading a specific line.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ReadLines reads lines from an io.Reader and strips UTF-8 BOM characters. Lines may be of any
+// length and end with LF, CRLF or a lone CR. Input that starts with a UTF-16 byte order mark,
+// little- or big-endian, is decoded to UTF-8.
 func ReadLines(reader io.Reader) ([]string, error) {
+	return ReadLinesMax(reader, 0)
+}
+
+// ReadLinesMax is like ReadLines, but fails with ErrLineTooLong once a line exceeds maxLength
+// bytes, not counting the line ending. A maxLength of zero means no limit. Failures are reported
+// as a LineError carrying the 1-based line number.
+func ReadLinesMax(reader io.Reader, maxLength int) ([]string, error) {
+	lines, _, err := readLines(reader, maxLength, false)
+	return lines, err
+}
+
+// ReadLinesTruncate is like ReadLinesMax, but instead of failing it discards the rest of a line
+// once it exceeds maxLength bytes and returns it as an empty line, so that line numbers are
+// preserved. Every such line is reported by a LineError for ErrLineTooLong in the second result.
+func ReadLinesTruncate(reader io.Reader, maxLength int) ([]string, []*LineError, error) {
+	return readLines(reader, maxLength, true)
+}
+
+// readLines implements ReadLinesMax and ReadLinesTruncate.
+func readLines(reader io.Reader, maxLength int, truncate bool) ([]string, []*LineError, error) {
 	if reader == nil {
-		return nil, fmt.Errorf("reader cannot be nil")
+		return nil, nil, fmt.Errorf("reader cannot be nil")
 	}
 
-	scanner := bufio.NewScanner(reader)
-	var lines []string
-	utf8BOM := []byte{0xEF, 0xBB, 0xBF}
+	r := bufio.NewReader(reader)
+	if bom, _ := r.Peek(2); bytes.Equal(bom, []byte{0xFF, 0xFE}) || bytes.Equal(bom, []byte{0xFE, 0xFF}) {
+		// The decoder consumes the byte order mark and uses it to pick the byte order
+		decoder := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		r = bufio.NewReader(transform.NewReader(r, decoder))
+	} else if bom, _ := r.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		r.Discard(3)
+	}
 
-	for lineNumber := 0; scanner.Scan(); lineNumber++ {
-		line := scanner.Bytes()
-		if lineNumber == 0 {
-			line = bytes.TrimPrefix(line, utf8BOM)
+	var lines []string
+	var tooLong []*LineError
+	var line []byte
+	afterCR := false
+	// discarding is set while the rest of a line that is too long is skipped
+	discarding := false
+	endLine := func() {
+		if discarding {
+			tooLong = append(tooLong, &LineError{Line: len(lines) + 1, Err: fmt.Errorf("%w: limit is %d bytes", ErrLineTooLong, maxLength)})
+			line = line[:0]
+			discarding = false
 		}
 		lines = append(lines, string(line))
+		line = line[:0]
 	}
+	for {
+		chunk, err := r.ReadSlice('\n')
+		for _, c := range chunk {
+			switch {
+			case c == '\n' && afterCR:
+				// The LF of a CRLF; the line already ended at the CR
+				afterCR = false
+			case c == '\n' || c == '\r':
+				endLine()
+				afterCR = c == '\r'
+			default:
+				afterCR = false
+				if discarding {
+					continue
+				}
+				if maxLength > 0 && len(line) >= maxLength {
+					if !truncate {
+						return nil, nil, &LineError{Line: len(lines) + 1, Err: fmt.Errorf("%w: limit is %d bytes", ErrLineTooLong, maxLength)}
+					}
+					discarding = true
+					continue
+				}
+				line = append(line, c)
+			}
+		}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading lines: %w", err)
+		switch err {
+		case nil, bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(line) > 0 || discarding {
+				endLine()
+			}
+			return lines, tooLong, nil
+		default:
+			return nil, nil, &LineError{Line: len(lines) + 1, Err: fmt.Errorf("error reading lines: %w", err)}
+		}
 	}
+}
+
+// ErrBadBracket is reported for character classes that cannot be compiled, such as "[z-a]".
+var ErrBadBracket = errors.New("invalid bracket expression")
+
+// ErrInvalidUTF8 is reported for patterns that are not valid UTF-8.
+var ErrInvalidUTF8 = errors.New("pattern is not valid UTF-8")
+
+// PatternError describes a problem at a specific byte offset of a pattern.
+type PatternError struct {
+	Offset int
+	Err    error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
 
-	return lines, nil
+func (e *PatternError) Unwrap() error {
+	return e.Err
 }
 
 // BuildRegex converts a gitignore-style pattern to a regular expression.
 // It properly handles wildcards, escaping, and gitignore-specific rules.
 func BuildRegex(pattern string) (*regexp.Regexp, error) {
+	return buildRegex(pattern, "")
+}
+
+// BuildSuffixRegex is like BuildRegex, but the regular expression also matches paths that end
+// with a match of the pattern starting right after a slash, so a single call tries every suffix.
+func BuildSuffixRegex(pattern string) (*regexp.Regexp, error) {
+	return buildRegex(pattern, "(.*/)?")
+}
+
+// buildRegex converts a pattern to a regular expression that also accepts prefix before it.
+func buildRegex(pattern, prefix string) (*regexp.Regexp, error) {
 	if pattern == "" {
 		return nil, fmt.Errorf("pattern cannot be empty")
 	}
+	if offset := invalidUTF8Offset(pattern); offset >= 0 {
+		return nil, &PatternError{Offset: offset, Err: ErrInvalidUTF8}
+	}
 
 	var regexBuilder strings.Builder
-	regexBuilder.WriteString("^")
+	// Paths may contain newlines, which "**" matches like any other character
+	regexBuilder.WriteString("(?s)^")
+	regexBuilder.WriteString(prefix)
 
 	i := 0
 	for i < len(pattern) {
@@ -50,39 +175,51 @@ func BuildRegex(pattern string) (*regexp.Regexp, error) {
 
 		switch char {
 		case '*':
-			if i+1 < len(pattern) && pattern[i+1] == '*' {
-				// Handle "**" double wildcard
-				i++ // consume the second '*'
-
-				// Check what follows the "**"
-				if i+1 < len(pattern) && pattern[i+1] == '/' {
-					// "**/" - matches zero or more directories
-					i++ // consume the '/'
-					regexBuilder.WriteString("(.*?/)?")
-				} else if i+1 == len(pattern) {
-					// "**" at end - matches anything
-					regexBuilder.WriteString(".*")
-				} else {
-					// "**" followed by something else - treat as ".*"
-					regexBuilder.WriteString(".*")
-				}
-			} else {
+			// A run of two or more stars is "**", which matches across slashes only when it
+			// makes up a whole path segment, as in git. Elsewhere it is an ordinary "*"
+			run := i
+			for i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++ // consume the following '*'
+			}
+			rest := pattern[i+1:]
+			segment := i > run && (run == 0 || pattern[run-1] == '/') &&
+				(rest == "" || rest[0] == '/' || strings.HasPrefix(rest, "\\/"))
+
+			switch {
+			case !segment:
 				// Single "*" - matches any characters except '/'
 				regexBuilder.WriteString("[^/]*")
+			case strings.HasPrefix(rest, "/"):
+				// "**/" - matches zero or more directories
+				i++ // consume the '/'
+				regexBuilder.WriteString("(.*?/)?")
+
+				// Chains like "**/**/**/" mean the same as a single "**/", and would
+				// otherwise expand into nested optional groups
+				for {
+					stars := len(pattern[i+1:]) - len(strings.TrimLeft(pattern[i+1:], "*"))
+					if stars < 2 || !strings.HasPrefix(pattern[i+1+stars:], "/") {
+						break
+					}
+					i += stars + 1
+				}
+			default:
+				// "**" at the end, or before an escaped slash - matches anything
+				regexBuilder.WriteString(".*")
 			}
 		case '?':
 			// Single character wildcard (except '/')
 			regexBuilder.WriteString("[^/]")
 		case '[':
 			// Character class - find the closing bracket
-			j := i + 1
-			for j < len(pattern) && pattern[j] != ']' {
-				j++
-			}
-			if j < len(pattern) {
-				// Valid character class - write it as-is (it's already valid regex)
+			if j := ClassEnd(pattern, i); j >= 0 {
+				// Character class - translate it, since its syntax differs from regex classes
 				charClass := pattern[i : j+1]
-				regexBuilder.WriteString(charClass)
+				class, err := translateClass(pattern[i+1 : j])
+				if err != nil {
+					return nil, &PatternError{Offset: i, Err: fmt.Errorf("%w %q: %v", ErrBadBracket, charClass, err)}
+				}
+				regexBuilder.WriteString(class)
 				i = j
 			} else {
 				// No closing bracket, treat as literal
@@ -103,8 +240,8 @@ func BuildRegex(pattern string) (*regexp.Regexp, error) {
 				}
 				regexBuilder.WriteByte(nextChar)
 			} else {
-				// Trailing backslash
-				regexBuilder.WriteString("\\\\")
+				// A trailing backslash escapes nothing, and git never matches the pattern
+				regexBuilder.WriteString(matchNothing)
 			}
 		default:
 			// Regular character
@@ -124,6 +261,196 @@ func BuildRegex(pattern string) (*regexp.Regexp, error) {
 	return regex, nil
 }
 
+// matchNothing is a regex that never matches.
+const matchNothing = "[^\\x00-\\x{10FFFF}]"
+
+// posixClasses holds the ranges of the character classes a bracket expression may name, such as
+// "[:alpha:]". As in git, they only contain ASCII characters, and "[:space:]" does not include
+// vertical tabs or form feeds.
+var posixClasses = map[string][][2]rune{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0x00, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\n'}, {'\r', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// translateClass converts the body of a bracket expression to a regex character class. A leading
+// "!" or "^" negates the class, "a-z" denotes a range, "[:alpha:]" and the like name a class, a
+// backslash escapes the next character and every other character stands for itself. As in git,
+// the class never matches "/", whether it is negated or lists it.
+func translateClass(body string) (string, error) {
+	runes := []rune(body)
+	negated := len(runes) > 0 && (runes[0] == '!' || runes[0] == '^')
+	if negated {
+		runes = runes[1:]
+	}
+
+	var ranges [][2]rune
+	// prev is the character before a "-"; a "-" first, or after a range or a named class, is
+	// literal, as in git
+	var prev rune
+	hasPrev := false
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\' && i+1 < len(runes):
+			i++
+			c = runes[i]
+		case c == '-' && hasPrev && i+1 < len(runes):
+			i++
+			hi := runes[i]
+			if hi == '\\' && i+1 < len(runes) {
+				i++
+				hi = runes[i]
+			}
+			if hi < prev {
+				return "", fmt.Errorf("invalid character class range %q", string(prev)+"-"+string(hi))
+			}
+			ranges = append(ranges, [2]rune{prev, hi})
+			hasPrev = false
+			continue
+		case c == '[' && i+1 < len(runes) && runes[i+1] == ':':
+			if end := classNameEnd(runes, i); end >= 0 {
+				name := string(runes[i+2 : end-1])
+				named, ok := posixClasses[name]
+				if !ok {
+					return "", fmt.Errorf("unknown character class %q", "[:"+name+":]")
+				}
+				ranges = append(ranges, named...)
+				hasPrev = false
+				i = end
+				continue
+			}
+		}
+		ranges = append(ranges, [2]rune{c, c})
+		prev, hasPrev = c, true
+	}
+
+	var class strings.Builder
+	class.WriteString("[")
+	if negated {
+		class.WriteString("^/")
+	}
+	empty := true
+	for _, r := range ranges {
+		lo, hi := r[0], r[1]
+		// Leave "/" out of the class, splitting a range around it if needed
+		if !negated && lo <= '/' && '/' <= hi {
+			if lo < '/' {
+				writeClassRange(&class, lo, '/'-1)
+				empty = false
+			}
+			if hi > '/' {
+				writeClassRange(&class, '/'+1, hi)
+				empty = false
+			}
+			continue
+		}
+		writeClassRange(&class, lo, hi)
+		empty = false
+	}
+	if empty && !negated {
+		// Nothing but "/" was listed
+		return matchNothing, nil
+	}
+	class.WriteString("]")
+	return class.String(), nil
+}
+
+// classNameEnd returns the index of the "]" that closes the class name, such as "[:alpha:]",
+// starting with the "[:" at runes[start], or -1 if there is none. As in git, the name ends at the
+// first "]", which must follow a ":".
+func classNameEnd(runes []rune, start int) int {
+	for k := start + 2; k < len(runes); k++ {
+		if runes[k] == ']' {
+			if k-1 >= start+2 && runes[k-1] == ':' {
+				return k
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// ClassEnd returns the index of the "]" that closes the bracket expression opened by the "[" at
+// pattern[start], or -1 if it is not closed. As in git, a "]" right after the "[" or "[!" is a
+// member of the class rather than its end, as is a "]" that ends a range or a class name such as
+// "[:alpha:]", and a backslash escapes the next character.
+func ClassEnd(pattern string, start int) int {
+	j := start + 1
+	if j < len(pattern) && (pattern[j] == '!' || pattern[j] == '^') {
+		j++
+	}
+	hasPrev := false
+	for first := true; j < len(pattern); first, j = false, j+1 {
+		switch c := pattern[j]; {
+		case c == ']' && !first:
+			return j
+		case c == '\\':
+			j++
+			hasPrev = true
+		case c == '-' && hasPrev && j+1 < len(pattern) && pattern[j+1] != ']':
+			j++
+			if pattern[j] == '\\' {
+				j++
+			}
+			hasPrev = false
+		case c == '[' && j+1 < len(pattern) && pattern[j+1] == ':':
+			k := strings.IndexByte(pattern[j+2:], ']')
+			if k < 0 {
+				return -1
+			}
+			if k >= 1 && pattern[j+2+k-1] == ':' {
+				j += 2 + k
+				hasPrev = false
+			} else {
+				hasPrev = true
+			}
+		default:
+			hasPrev = true
+		}
+	}
+	return -1
+}
+
+// writeClassRange writes the range lo-hi, or the single character lo, to a regex character class.
+func writeClassRange(class *strings.Builder, lo, hi rune) {
+	writeClassRune(class, lo)
+	if hi != lo {
+		class.WriteString("-")
+		writeClassRune(class, hi)
+	}
+}
+
+// writeClassRune writes r to a regex character class, escaping it if needed.
+func writeClassRune(class *strings.Builder, r rune) {
+	switch r {
+	case '\\', '[', ']', '^', '-':
+		class.WriteByte('\\')
+	}
+	class.WriteRune(r)
+}
+
+// invalidUTF8Offset returns the byte offset of the first invalid UTF-8 sequence in s, or -1.
+func invalidUTF8Offset(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // isRegexMetaChar checks if a character has special meaning in regex
 func isRegexMetaChar(char byte) bool {
 	switch char {
@@ -144,8 +471,8 @@ func NormalizePath(path string) string {
 		path = strings.ReplaceAll(path, "//", "/")
 	}
 
-	// Remove leading "./"
-	if strings.HasPrefix(path, "./") {
+	// Remove leading "./", repeatedly so that the result is normalized as well
+	for strings.HasPrefix(path, "./") {
 		path = path[2:]
 	}
 
@@ -2,6 +2,8 @@ package internal
 
 import (
 	"bytes"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -52,7 +54,31 @@ func TestReadLines(t *testing.T) {
 		{
 			name:       "Mixed line endings",
 			input:      "line1\nline2\rline3\r\nline4",
-			expected:   []string{"line1", "line2\rline3", "line4"},
+			expected:   []string{"line1", "line2", "line3", "line4"},
+			shouldFail: false,
+		},
+		{
+			name:       "Empty lines with CR endings",
+			input:      "a\r\r\nb\r",
+			expected:   []string{"a", "", "b"},
+			shouldFail: false,
+		},
+		{
+			name:       "Line longer than 64KiB",
+			input:      strings.Repeat("x", 200*1024) + "\nshort\n",
+			expected:   []string{strings.Repeat("x", 200*1024), "short"},
+			shouldFail: false,
+		},
+		{
+			name:       "UTF-16 little-endian",
+			input:      "\xff\xfe*\x00.\x00l\x00o\x00g\x00\r\x00\n\x00c\x00a\x00f\x00\xe9\x00/\x00",
+			expected:   []string{"*.log", "café/"},
+			shouldFail: false,
+		},
+		{
+			name:       "UTF-16 big-endian",
+			input:      "\xfe\xff\x00*\x00.\x00l\x00o\x00g\x00\n\xd8\x3d\xde\x00",
+			expected:   []string{"*.log", "\U0001F600"},
 			shouldFail: false,
 		},
 	}
@@ -88,6 +114,83 @@ func TestReadLines(t *testing.T) {
 	}
 }
 
+func TestReadLinesMax(t *testing.T) {
+	input := "short\n" + strings.Repeat("y", 10) + "\r\n" + strings.Repeat("z", 11) + "\n"
+
+	lines, err := ReadLinesMax(strings.NewReader(input), 11)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 lines, got %d", len(lines))
+	}
+
+	_, err = ReadLinesMax(strings.NewReader(input), 10)
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("Expected a LineError wrapping ErrLineTooLong, got %v", err)
+	}
+	if lineErr.Line != 3 {
+		t.Errorf("Expected line 3, got %d", lineErr.Line)
+	}
+}
+
+func TestReadLinesTruncate(t *testing.T) {
+	input := "short\n" + strings.Repeat("y", 11) + "\r\nnext\n" + strings.Repeat("z", 100000)
+
+	lines, tooLong, err := ReadLinesTruncate(strings.NewReader(input), 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"short", "", "next", ""}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+	if len(tooLong) != 2 || tooLong[0].Line != 2 || tooLong[1].Line != 4 || !errors.Is(tooLong[0], ErrLineTooLong) {
+		t.Errorf("Expected lines 2 and 4 to be too long, got %v", tooLong)
+	}
+}
+
+func TestReadLinesUTF16MaxLength(t *testing.T) {
+	// The maximum applies to the decoded UTF-8 bytes
+	input := "\xff\xfea\x00\n\x00\xe9\x00\xe9\x00\n\x00"
+
+	if _, err := ReadLinesMax(strings.NewReader(input), 4); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err := ReadLinesMax(strings.NewReader(input), 3)
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Errorf("Expected a LineError on line 2, got %v", err)
+	}
+}
+
+// failingReader returns data and then a read error.
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("disk error")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadLinesReadError(t *testing.T) {
+	_, err := ReadLines(&failingReader{data: "a\nb\nc"})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("Expected a LineError, got %v", err)
+	}
+	if lineErr.Line != 3 {
+		t.Errorf("Expected line 3, got %d", lineErr.Line)
+	}
+}
+
 func TestReadLinesNilReader(t *testing.T) {
 	_, err := ReadLines(nil)
 	if err == nil {
@@ -213,6 +316,86 @@ func TestBuildRegex(t *testing.T) {
 				"fileXtest.txt", "file$test.txt", "file(test).txt",
 			},
 		},
+		{
+			name:    "Negated character class",
+			pattern: "[!x]y",
+			shouldPass: []string{
+				"ay", "!y",
+			},
+			shouldFail: []string{
+				"xy", "y",
+			},
+		},
+		{
+			name:    "Character class with escapes and literal dash",
+			pattern: "[a\\-c-]z",
+			shouldPass: []string{
+				"az", "cz", "-z",
+			},
+			shouldFail: []string{
+				"bz", "\\z",
+			},
+		},
+		{
+			name:    "Leading bracket is a class member",
+			pattern: "[]abc]",
+			shouldPass: []string{
+				"a", "]", "c",
+			},
+			shouldFail: []string{
+				"d", "[]abc]",
+			},
+		},
+		{
+			name:    "Lone bracket in a class",
+			pattern: "[]]",
+			shouldPass: []string{
+				"]",
+			},
+			shouldFail: []string{
+				"", "]]",
+			},
+		},
+		{
+			name:    "Leading bracket in a negated class",
+			pattern: "[!]]x",
+			shouldPass: []string{
+				"ax",
+			},
+			shouldFail: []string{
+				"]x",
+			},
+		},
+		{
+			name:    "Escaped bracket in a class",
+			pattern: "[a\\]]z",
+			shouldPass: []string{
+				"az", "]z",
+			},
+			shouldFail: []string{
+				"\\z", "bz",
+			},
+		},
+		{
+			name:    "Bracket pair without members is literal",
+			pattern: "x[]",
+			shouldPass: []string{
+				"x[]",
+			},
+			shouldFail: []string{
+				"x",
+			},
+		},
+		{
+			name:    "Character class is not a regex class",
+			pattern: "[\\d]",
+			shouldPass: []string{
+				"d",
+			},
+			shouldFail: []string{
+				"1",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -255,10 +438,11 @@ func TestBuildRegexEdgeCases(t *testing.T) {
 		want    bool
 	}{
 		{
+			// As in git, a trailing backslash escapes nothing and the pattern never matches
 			name:    "Trailing backslash",
 			pattern: "file\\",
 			input:   "file\\",
-			want:    true,
+			want:    false,
 		},
 		{
 			name:    "Escaped question mark",
@@ -284,6 +468,60 @@ func TestBuildRegexEdgeCases(t *testing.T) {
 			input:   "dir/sub/file.txt",
 			want:    false,
 		},
+		{
+			name:    "Double asterisk inside a segment",
+			pattern: "a**b",
+			input:   "a/x/b",
+			want:    false,
+		},
+		{
+			name:    "Double asterisk across a newline",
+			pattern: "**",
+			input:   "a\nb",
+			want:    true,
+		},
+		{
+			name:    "Negated class and slash",
+			pattern: "a[!b]c",
+			input:   "a/c",
+			want:    false,
+		},
+		{
+			name:    "Class listing a slash",
+			pattern: "a[/]c",
+			input:   "a/c",
+			want:    false,
+		},
+		{
+			name:    "Range spanning a slash",
+			pattern: "a[+-0]c",
+			input:   "a/c",
+			want:    false,
+		},
+		{
+			name:    "Range spanning a slash, other member",
+			pattern: "a[+-0]c",
+			input:   "a.c",
+			want:    true,
+		},
+		{
+			name:    "Named class",
+			pattern: "a[[:digit:]x]c",
+			input:   "a7c",
+			want:    true,
+		},
+		{
+			name:    "Space class without vertical tab",
+			pattern: "a[[:space:]]c",
+			input:   "a\vc",
+			want:    false,
+		},
+		{
+			name:    "Range after a named class",
+			pattern: "a[[:digit:]-z]c",
+			input:   "a-c",
+			want:    true,
+		},
 	}
 
 	for _, test := range tests {
@@ -352,6 +590,11 @@ func TestNormalizePath(t *testing.T) {
 			input:    "./",
 			expected: "",
 		},
+		{
+			name:     "Repeated leading dot slash",
+			input:    "./././/path/file",
+			expected: "path/file",
+		},
 	}
 
 	for _, test := range tests {
@@ -406,3 +649,105 @@ func BenchmarkRegexMatch(b *testing.B) {
 		}
 	}
 }
+
+func TestBuildRegexBadBracket(t *testing.T) {
+	tests := []struct {
+		pattern string
+		offset  int
+	}{
+		{"abc[z-a]", 3},
+		{"a/b[]z-a]c", 3},
+		{"[!z-a]", 0},
+		{"x[[:bogus:]]", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			_, err := BuildRegex(test.pattern)
+			var patternErr *PatternError
+			if !errors.As(err, &patternErr) {
+				t.Fatalf("Expected a *PatternError, got %v", err)
+			}
+			if patternErr.Offset != test.offset {
+				t.Errorf("Expected offset %d, got %d", test.offset, patternErr.Offset)
+			}
+			if !errors.Is(err, ErrBadBracket) {
+				t.Errorf("Expected error to wrap ErrBadBracket, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildRegexInvalidUTF8(t *testing.T) {
+	_, err := BuildRegex("ab\xffc")
+	var patternErr *PatternError
+	if !errors.As(err, &patternErr) {
+		t.Fatalf("Expected a *PatternError, got %v", err)
+	}
+	if patternErr.Offset != 2 || !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("Expected ErrInvalidUTF8 at offset 2, got %v", err)
+	}
+}
+
+func TestBuildRegexDoubleStarChain(t *testing.T) {
+	single, err := BuildRegex("a/**/b")
+	if err != nil {
+		t.Fatalf("BuildRegex failed: %v", err)
+	}
+
+	chain := "a/" + strings.Repeat("**/", 1000) + "b"
+	regex, err := BuildRegex(chain)
+	if err != nil {
+		t.Fatalf("BuildRegex failed: %v", err)
+	}
+	if regex.String() != single.String() {
+		t.Errorf("Expected a chain of **/ to compile like a single one, got %q", regex)
+	}
+
+	for input, want := range map[string]bool{"a/b": true, "a/x/y/b": true, "a/xb": false, "b": false} {
+		if got := regex.MatchString(input); got != want {
+			t.Errorf("Pattern %q against %q: expected %v, got %v", "a/**/**/b", input, want, got)
+		}
+	}
+}
+
+func TestBuildRegexStarRuns(t *testing.T) {
+	// Runs of more than two stars mean the same as "**"
+	tests := []struct {
+		run    string
+		double string
+	}{
+		{"***/foo", "**/foo"},
+		{"a/***/b", "a/**/b"},
+		{"a/****/***/**/b", "a/**/b"},
+		{"a/***", "a/**"},
+		{"a***b", "a**b"},
+	}
+
+	for _, test := range tests {
+		run, err := BuildRegex(test.run)
+		if err != nil {
+			t.Fatalf("BuildRegex(%q) failed: %v", test.run, err)
+		}
+		double, err := BuildRegex(test.double)
+		if err != nil {
+			t.Fatalf("BuildRegex(%q) failed: %v", test.double, err)
+		}
+		if run.String() != double.String() {
+			t.Errorf("Expected %q to compile like %q, got %q and %q", test.run, test.double, run, double)
+		}
+	}
+}
+
+func TestBuildSuffixRegex(t *testing.T) {
+	regex, err := BuildSuffixRegex("b/*.txt")
+	if err != nil {
+		t.Fatalf("BuildSuffixRegex failed: %v", err)
+	}
+
+	for input, want := range map[string]bool{"b/c.txt": true, "a/b/c.txt": true, "x/y/b/c.txt": true, "ab/c.txt": false, "b/c.txt/d": false} {
+		if got := regex.MatchString(input); got != want {
+			t.Errorf("Pattern %q against %q: expected %v, got %v", "b/*.txt", input, want, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package dotignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits bounds the resources a PatternMatcher may use, for rule sets and paths from untrusted
+// sources. A zero field means no limit.
+type Limits struct {
+	// MaxPatterns is the maximum number of patterns, not counting blank lines and comments.
+	MaxPatterns int
+	// MaxPatternLength is the maximum length of a line in bytes.
+	MaxPatternLength int
+	// MaxDoubleStars is the maximum number of "**" wildcards in a single pattern.
+	MaxDoubleStars int
+	// MaxPathLength is the maximum length in bytes of a path to match, after normalization.
+	MaxPathLength int
+	// MaxPathDepth is the maximum number of components of a path to match.
+	MaxPathDepth int
+}
+
+// DefaultLimits are limits suitable for ignore files from arbitrary repositories. They are well
+// above what real ignore files and file systems need.
+var DefaultLimits = Limits{
+	MaxPatterns:      10000,
+	MaxPatternLength: 4096,
+	MaxDoubleStars:   16,
+	MaxPathLength:    4096,
+	MaxPathDepth:     256,
+}
+
+// applyLimits checks the pattern lines against the limits. It returns the lines with the ones
+// that exceed a limit blanked out, so that line numbers are preserved, and an error diagnostic
+// for each of them. Once MaxPatterns is reached, all further patterns are dropped with a single
+// diagnostic.
+func applyLimits(patterns []string, limits Limits) ([]string, []Diagnostic) {
+	if limits == (Limits{}) {
+		return patterns, nil
+	}
+
+	var kept []string
+	var diagnostics []Diagnostic
+	count := 0
+	for i, line := range patterns {
+		reason := lineLimitError(line, limits)
+		tooMany := false
+		if reason == nil && isPatternLine(line) {
+			count++
+			if limits.MaxPatterns > 0 && count > limits.MaxPatterns {
+				reason = fmt.Errorf("%w: limit is %d", ErrTooManyPatterns, limits.MaxPatterns)
+				tooMany = true
+			}
+		}
+		if reason == nil {
+			continue
+		}
+
+		if kept == nil {
+			kept = append([]string(nil), patterns...)
+		}
+		diagnostics = append(diagnostics, Diagnostic{Severity: SeverityError, Line: i + 1, Column: 1, Pattern: line, Reason: reason})
+		if tooMany {
+			for j := i; j < len(kept); j++ {
+				kept[j] = ""
+			}
+			break
+		}
+		kept[i] = ""
+	}
+
+	if kept == nil {
+		return patterns, nil
+	}
+	return kept, diagnostics
+}
+
+// isPatternLine reports whether a line holds a pattern rather than being blank or a comment.
+func isPatternLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !strings.HasPrefix(trimmed, "#")
+}
+
+// lineLimitError returns the reason a single line exceeds the limits, or nil.
+func lineLimitError(line string, limits Limits) error {
+	if limits.MaxPatternLength > 0 && len(line) > limits.MaxPatternLength {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrPatternTooLong, len(line), limits.MaxPatternLength)
+	}
+	if limits.MaxDoubleStars > 0 && isPatternLine(line) {
+		if n := strings.Count(line, "**"); n > limits.MaxDoubleStars {
+			return fmt.Errorf("%w: %d, limit is %d", ErrTooManyDoubleStars, n, limits.MaxDoubleStars)
+		}
+	}
+	return nil
+}
+
+// checkPath checks a normalized path against the path limits.
+func (p *PatternMatcher) checkPath(file string) error {
+	limits := p.options.limits
+	if limits.MaxPathLength > 0 && len(file) > limits.MaxPathLength {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrPathTooLong, len(file), limits.MaxPathLength)
+	}
+	if limits.MaxPathDepth > 0 {
+		if depth := strings.Count(file, "/") + 1; depth > limits.MaxPathDepth {
+			return fmt.Errorf("%w: %d components, limit is %d", ErrPathTooDeep, depth, limits.MaxPathDepth)
+		}
+	}
+	return nil
+}
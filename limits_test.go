@@ -0,0 +1,155 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithLimitsPatterns(t *testing.T) {
+	limits := Limits{MaxPatterns: 2, MaxPatternLength: 20, MaxDoubleStars: 2}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		line     int
+		reason   error
+	}{
+		{"too many patterns", []string{"# comment", "a", "", "b", "c"}, 5, ErrTooManyPatterns},
+		{"pattern too long", []string{"a", strings.Repeat("x", 21)}, 2, ErrPatternTooLong},
+		{"too many double stars", []string{"**/a/**/b/**"}, 1, ErrTooManyDoubleStars},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewPatternMatcher(test.patterns, WithLimits(limits))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a *ParseError, got %v", err)
+			}
+			if parseErr.Line != test.line {
+				t.Errorf("Expected line %d, got %d", test.line, parseErr.Line)
+			}
+			if !errors.Is(err, test.reason) {
+				t.Errorf("Expected error to wrap %v, got %v", test.reason, err)
+			}
+
+			matcher, diagnostics := NewPatternMatcherLenient(test.patterns, WithLimits(limits))
+			if len(diagnostics) != 1 || diagnostics[0].Line != test.line || !errors.Is(diagnostics[0].Reason, test.reason) {
+				t.Errorf("Expected one diagnostic at line %d, got %v", test.line, diagnostics)
+			}
+			if matcher == nil {
+				t.Fatal("Expected a lenient matcher")
+			}
+		})
+	}
+
+	// Patterns within the limits are unaffected, and lenient parsing keeps the first ones
+	matcher, diagnostics := NewPatternMatcherLenient([]string{"*.log", "build/", "*.tmp", "*.bak"}, WithLimits(limits))
+	if len(diagnostics) != 1 || diagnostics[0].Line != 3 {
+		t.Errorf("Expected one diagnostic at line 3, got %v", diagnostics)
+	}
+	for file, expected := range map[string]bool{"a.log": true, "build": true, "a.tmp": false, "a.bak": false} {
+		if result, _ := matcher.Matches(file); result != expected {
+			t.Errorf("File %q: expected %v, got %v", file, expected, result)
+		}
+	}
+
+	if _, err := NewPatternMatcher([]string{"**/a/**", "b"}, WithLimits(limits)); err != nil {
+		t.Errorf("Unexpected error within limits: %v", err)
+	}
+}
+
+func TestWithLimitsPaths(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"*.log"}, WithLimits(Limits{MaxPathLength: 16, MaxPathDepth: 3}))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		reason error
+	}{
+		{"a/b/c.log", nil},
+		{"./a//b/c.log/", nil},
+		{"a/b/c/d.log", ErrPathTooDeep},
+		{"abcdefghijklmnopq.log", ErrPathTooLong},
+	}
+
+	for _, test := range tests {
+		checks := map[string]func() error{
+			"MatchesPath": func() error { _, err := matcher.MatchesPath(test.path, false); return err },
+			"Matches":     func() error { _, err := matcher.Matches(test.path); return err },
+			"Cursor":      func() error { _, err := matcher.NewCursor().Matches(test.path); return err },
+			"Explain":     func() error { _, err := matcher.Explain(test.path, false); return err },
+			"Coverage":    func() error { _, err := NewCoverage(matcher).Matches(test.path); return err },
+			"MatchAll":    func() error { _, err := matcher.MatchAll([]string{test.path}); return err },
+		}
+		for name, check := range checks {
+			err := check()
+			if test.reason == nil && err != nil {
+				t.Errorf("%s(%q): unexpected error %v", name, test.path, err)
+			}
+			if test.reason != nil && !errors.Is(err, test.reason) {
+				t.Errorf("%s(%q): expected %v, got %v", name, test.path, test.reason, err)
+			}
+		}
+	}
+}
+
+func TestWithLimitsLongLineFromFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), ".gitignore")
+	content := "*.log\n" + strings.Repeat("x", 1<<20) + "\nbuild/\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	opt := WithLimits(Limits{MaxPatternLength: 100})
+
+	_, err := NewPatternMatcherFromFile(filePath, opt)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrPatternTooLong) {
+		t.Fatalf("Expected a ParseError for ErrPatternTooLong, got %v", err)
+	}
+	if parseErr.Line != 2 || parseErr.File != filePath {
+		t.Errorf("Expected error at %s:2, got %s:%d", filePath, parseErr.File, parseErr.Line)
+	}
+
+	if _, err := NewPatternMatcherFromReader(strings.NewReader(content), opt); !errors.Is(err, ErrPatternTooLong) {
+		t.Errorf("Expected ErrPatternTooLong from reader, got %v", err)
+	}
+
+	matcher, diagnostics, err := NewPatternMatcherFromFileLenient(filePath, opt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 2 {
+		t.Fatalf("Expected one diagnostic on line 2, got %v", diagnostics)
+	}
+	// Only the first bytes of the line are read, the rest is discarded
+	d := diagnostics[0]
+	if !errors.Is(d.Reason, ErrPatternTooLong) || d.Column != 101 || d.File != filePath || d.Pattern != "" {
+		t.Errorf("Unexpected diagnostic %+v", d)
+	}
+	if ignored, err := matcher.MatchesPath("build", true); err != nil || !ignored {
+		t.Errorf("Expected build to be ignored, got %v (%v)", ignored, err)
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	patterns := []string{"*.log", "**/node_modules/**", "!keep.log", "build/"}
+	matcher, err := NewPatternMatcher(patterns, WithLimits(DefaultLimits))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if ignored, err := matcher.Matches("src/node_modules/pkg/index.js"); err != nil || !ignored {
+		t.Errorf("Expected path to be ignored, got %v (%v)", ignored, err)
+	}
+
+	deep := strings.Repeat("d/", DefaultLimits.MaxPathDepth) + "f.log"
+	if _, err := matcher.Matches(deep); !errors.Is(err, ErrPathTooDeep) {
+		t.Errorf("Expected ErrPathTooDeep, got %v", err)
+	}
+}
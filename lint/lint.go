@@ -0,0 +1,330 @@
+// Package lint analyzes ignore files for rules that are redundant, ineffective or likely to be
+// misread, such as duplicates, negations that git can never apply and patterns whose meaning
+// changes during parsing.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore"
+	"github.com/codeglyph/go-dotignore/internal"
+)
+
+// Check identifies the kind of problem a Finding reports.
+type Check string
+
+const (
+	// CheckParse reports lines that cannot be parsed and are skipped.
+	CheckParse Check = "parse"
+	// CheckDuplicate reports patterns that repeat an earlier line exactly.
+	CheckDuplicate Check = "duplicate"
+	// CheckDeadNegation reports negations that can never take effect because a parent
+	// directory of the paths they re-include is excluded, which git does not look into.
+	CheckDeadNegation Check = "dead-negation"
+	// CheckShadowed reports patterns whose every match is decided by a later pattern.
+	CheckShadowed Check = "shadowed"
+	// CheckWhitespace reports leading or trailing whitespace that is removed during parsing.
+	CheckWhitespace Check = "whitespace"
+	// CheckDoubleStar reports "**" forms that are redundant or do not mean what they appear to.
+	CheckDoubleStar Check = "double-star"
+	// CheckBackslash reports backslashes, which are converted to path separators.
+	CheckBackslash Check = "backslash"
+	// CheckBracket reports brackets that are matched literally.
+	CheckBracket Check = "bracket"
+)
+
+// Finding is a single problem reported by Lint.
+type Finding struct {
+	Check    Check
+	Severity dotignore.Severity
+	// File is the ignore file the line was read from, if any.
+	File string
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based byte column the problem starts at.
+	Column int
+	// Pattern is the line as it was given.
+	Pattern string
+	Message string
+}
+
+func (f Finding) String() string {
+	location := fmt.Sprintf("%d:%d", f.Line, f.Column)
+	if f.File != "" {
+		location = f.File + ":" + location
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, f.Severity, f.Message, f.Check)
+}
+
+// rule is a parsed, valid line of the pattern list.
+type rule struct {
+	line    int
+	text    string // line with surrounding whitespace removed
+	pattern string // text without negation and with separators normalized
+	negate  bool
+	isDir   bool
+}
+
+// Lint analyzes a list of patterns, one per line, and returns its findings ordered by line.
+func Lint(patterns []string) []Finding {
+	_, diagnostics := dotignore.NewPatternMatcherLenient(patterns)
+
+	var findings []Finding
+	invalid := make(map[int]bool)
+	for _, d := range diagnostics {
+		if d.Severity == dotignore.SeverityError {
+			invalid[d.Line] = true
+		}
+		findings = append(findings, fromDiagnostic(d))
+	}
+
+	var rules []rule
+	for i, line := range patterns {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") || invalid[i+1] {
+			continue
+		}
+		r := rule{line: i + 1, text: text}
+		r.pattern = strings.TrimPrefix(text, "!")
+		r.negate = len(r.pattern) < len(text)
+		r.pattern = strings.ReplaceAll(r.pattern, "\\", "/")
+		r.isDir = strings.HasSuffix(r.pattern, "/")
+		r.pattern = strings.TrimSuffix(r.pattern, "/")
+		rules = append(rules, r)
+
+		findings = append(findings, doubleStarFindings(line, r)...)
+	}
+
+	findings = append(findings, duplicateFindings(patterns, rules)...)
+	findings = append(findings, shadowedFindings(patterns, rules)...)
+	findings = append(findings, deadNegationFindings(patterns, rules)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+// fromDiagnostic converts a lenient parsing diagnostic into a finding.
+func fromDiagnostic(d dotignore.Diagnostic) Finding {
+	check := CheckParse
+	switch {
+	case errors.Is(d.Reason, dotignore.ErrLeadingWhitespace), errors.Is(d.Reason, dotignore.ErrTrailingWhitespace):
+		check = CheckWhitespace
+	case errors.Is(d.Reason, dotignore.ErrBackslashSeparator):
+		check = CheckBackslash
+	case errors.Is(d.Reason, dotignore.ErrUnclosedBracket):
+		check = CheckBracket
+	}
+	return Finding{
+		Check:    check,
+		Severity: d.Severity,
+		File:     d.File,
+		Line:     d.Line,
+		Column:   d.Column,
+		Pattern:  d.Pattern,
+		Message:  d.Reason.Error(),
+	}
+}
+
+// doubleStarFindings reports runs of three or more stars, repeated "**/" segments and "**" that
+// is not delimited by slashes, which git treats like a single "*".
+func doubleStarFindings(line string, r rule) []Finding {
+	var findings []Finding
+	offset := strings.Index(line, r.text)
+	report := func(index int, message string) {
+		findings = append(findings, warning(CheckDoubleStar, line, r.line, offset+index+1, message))
+	}
+
+	text := strings.ReplaceAll(r.text, "\\", "/")
+	for i := 0; i < len(text); {
+		if text[i] != '*' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && text[j] == '*' {
+			j++
+		}
+
+		switch {
+		case j-i > 2:
+			report(i, fmt.Sprintf("%q is equivalent to \"**\"", text[i:j]))
+		case j-i == 2:
+			startsSegment := i == 0 || text[i-1] == '/' || (i == 1 && r.negate)
+			endsSegment := j == len(text) || text[j] == '/'
+			if !startsSegment || !endsSegment {
+				report(i, "\"**\" not delimited by slashes matches like a single \"*\"")
+			} else if strings.HasPrefix(text[j:], "/**/") || text[j:] == "/**" {
+				report(i, "repeated \"**\" segments are redundant")
+			}
+		}
+		i = j
+	}
+	return findings
+}
+
+// duplicateFindings reports lines that repeat an earlier rule exactly.
+func duplicateFindings(patterns []string, rules []rule) []Finding {
+	var findings []Finding
+	first := make(map[string]int)
+	for _, r := range rules {
+		if line, ok := first[r.text]; ok {
+			findings = append(findings, warning(CheckDuplicate, patterns[r.line-1], r.line, column(patterns[r.line-1]),
+				fmt.Sprintf("duplicate of line %d", line)))
+			continue
+		}
+		first[r.text] = r.line
+	}
+	return findings
+}
+
+// shadowedFindings reports literal patterns that a later pattern always overrides. Patterns with
+// wildcards are only compared against later patterns that are textually identical apart from
+// negation, since deciding whether one glob contains another is not attempted.
+func shadowedFindings(patterns []string, rules []rule) []Finding {
+	var findings []Finding
+	for i, r := range rules {
+		for _, later := range rules[i+1:] {
+			if later.text == r.text {
+				// Reported as a duplicate
+				break
+			}
+			if !covers(later, r) {
+				continue
+			}
+
+			effect := "overrides"
+			if later.negate == r.negate {
+				effect = "repeats"
+			}
+			findings = append(findings, warning(CheckShadowed, patterns[r.line-1], r.line, column(patterns[r.line-1]),
+				fmt.Sprintf("shadowed by line %d, which %s it for every path it matches", later.line, effect)))
+			break
+		}
+	}
+	return findings
+}
+
+// covers reports whether every path matched by r is also matched by later.
+func covers(later, r rule) bool {
+	if later.pattern == r.pattern {
+		// Only the negation or the directory flag differs; a file pattern covers the
+		// directory-only form, but not the other way round
+		return !later.isDir || r.isDir
+	}
+	if hasMeta(r.pattern) {
+		return false
+	}
+
+	matcher, err := dotignore.NewPatternMatcher([]string{strings.TrimPrefix(later.text, "!")})
+	if err != nil {
+		return false
+	}
+	matched, err := matcher.MatchesPath(r.pattern, r.isDir)
+	return err == nil && matched
+}
+
+// deadNegationFindings reports negations whose literal parent directory is excluded by the rules
+// before them. git does not descend into excluded directories, so nothing below them can be
+// re-included.
+func deadNegationFindings(patterns []string, rules []rule) []Finding {
+	var findings []Finding
+	for i, r := range rules {
+		if !r.negate || i == 0 {
+			continue
+		}
+
+		dir := literalParent(r.pattern)
+		if dir == "" {
+			continue
+		}
+
+		var before []string
+		for _, earlier := range rules[:i] {
+			before = append(before, earlier.text)
+		}
+		matcher, err := dotignore.NewPatternMatcher(before)
+		if err != nil {
+			continue
+		}
+
+		// The cursor applies git's rule that nothing below an excluded directory is re-included
+		cursor := matcher.NewCursor()
+		if ignored, err := cursor.Matches(dir); err == nil && ignored {
+			findings = append(findings, warning(CheckDeadNegation, patterns[r.line-1], r.line, column(patterns[r.line-1]),
+				fmt.Sprintf("negation has no effect because directory %q is excluded", dir)))
+		}
+	}
+	return findings
+}
+
+// literalParent returns the directory part of a pattern up to its first wildcard, or "" if the
+// pattern has no literal parent directory.
+func literalParent(pattern string) string {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if index := strings.IndexAny(pattern, "*?["); index >= 0 {
+		pattern = pattern[:index]
+		if slash := strings.LastIndex(pattern, "/"); slash >= 0 {
+			return pattern[:slash]
+		}
+		return ""
+	}
+	if slash := strings.LastIndex(pattern, "/"); slash >= 0 {
+		return pattern[:slash]
+	}
+	return ""
+}
+
+// hasMeta reports whether a pattern contains wildcard or bracket characters.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// column returns the 1-based column of the first non-space character of a line.
+func column(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t")) + 1
+}
+
+// warning builds a warning finding.
+func warning(check Check, line string, lineNumber, column int, message string) Finding {
+	return Finding{
+		Check:    check,
+		Severity: dotignore.SeverityWarning,
+		Line:     lineNumber,
+		Column:   column,
+		Pattern:  line,
+		Message:  message,
+	}
+}
+
+// LintFile reads an ignore file and lints its lines. Findings carry the file path.
+func LintFile(filePath string) ([]Finding, error) {
+	if filePath == "" {
+		return nil, errors.New("file path cannot be empty")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %w", filePath, err)
+	}
+	defer file.Close()
+
+	lines, err := internal.ReadLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", filePath, err)
+	}
+
+	findings := Lint(lines)
+	for i := range findings {
+		findings[i].File = filePath
+	}
+	return findings, nil
+}
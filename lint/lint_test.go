@@ -0,0 +1,102 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore"
+)
+
+func TestLint(t *testing.T) {
+	patterns := []string{
+		"# Build outputs", // 1
+		"build/",          // 2
+		"!build/keep.txt", // 3: dead negation, build/ is excluded
+		"*.log",           // 4
+		"*.log",           // 5: duplicate
+		"debug.tmp",       // 6: shadowed by line 8
+		"logs/**/**/x",    // 7: redundant **
+		"*.tmp",           // 8
+		"src/***/gen",     // 9: *** is **
+		"foo**",           // 10: ** not delimited
+		"vendor\\lib",     // 11: backslash
+		"dist/  ",         // 12: trailing whitespace
+		"!",               // 13: parse error
+		"out/**",          // 14
+		"!out/keep.txt",   // 15: fine, out/** does not exclude out itself
+		"!trace.log",      // 16: fine, re-includes a file
+		"notes.md",        // 17: shadowed by negation on line 18
+		"!notes.md",       // 18
+	}
+
+	expected := []struct {
+		line  int
+		check Check
+	}{
+		{3, CheckDeadNegation},
+		{5, CheckDuplicate},
+		{6, CheckShadowed},
+		{7, CheckDoubleStar},
+		{9, CheckDoubleStar},
+		{10, CheckDoubleStar},
+		{11, CheckBackslash},
+		{12, CheckWhitespace},
+		{13, CheckParse},
+		{17, CheckShadowed},
+	}
+
+	findings := Lint(patterns)
+	if len(findings) != len(expected) {
+		for _, finding := range findings {
+			t.Log(finding)
+		}
+		t.Fatalf("Expected %d findings, got %d", len(expected), len(findings))
+	}
+
+	for i, want := range expected {
+		got := findings[i]
+		if got.Line != want.line || got.Check != want.check {
+			t.Errorf("Finding %d: expected %s on line %d, got %v", i, want.check, want.line, got)
+		}
+		if got.Pattern != patterns[got.Line-1] {
+			t.Errorf("Finding %d: expected pattern %q, got %q", i, patterns[got.Line-1], got.Pattern)
+		}
+	}
+
+	if findings[8].Severity != dotignore.SeverityError {
+		t.Errorf("Expected parse finding to be an error, got %v", findings[8].Severity)
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	patterns := []string{"# Dependencies", "node_modules/", "", "*.log", "!important.log", "**/dist/", "docs/**"}
+	if findings := Lint(patterns); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("*.log\n*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	findings, err := LintFile(path)
+	if err != nil {
+		t.Fatalf("LintFile failed: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %v", findings)
+	}
+
+	expected := path + ":2:1: warning: duplicate of line 1 [duplicate]"
+	if findings[0].String() != expected {
+		t.Errorf("Expected %q, got %q", expected, findings[0].String())
+	}
+
+	if _, err := LintFile(filepath.Join(t.TempDir(), "missing")); err == nil || !strings.Contains(err.Error(), "failed to open") {
+		t.Errorf("Expected open error for missing file, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package dotignore
+
+import "path/filepath"
+
+// Option configures optional behavior of a PatternMatcher.
+type Option func(*options)
+
+// options holds the resolved configuration of a PatternMatcher.
+type options struct {
+	workers   int
+	cacheSize int
+	tracked   TrackedSet
+	limits    Limits
+	normalize bool
+	root      string
+	// rootSet records that the root was given with WithRoot rather than derived from a file
+	rootSet bool
+
+	// sourceFile is the ignore file the patterns were read from, if any
+	sourceFile string
+}
+
+// buildOptions applies opts on top of the defaults.
+func buildOptions(opts []Option) options {
+	o := options{
+		workers: 1,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithWorkers sets the number of goroutines that MatchAll and Filter fan out across.
+// Values below 1 are treated as 1, which evaluates paths on the calling goroutine.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = 1
+		}
+		o.workers = n
+	}
+}
+
+// WithCache enables a bounded cache of up to size results, keyed by normalized path and whether
+// the path is a directory. Matchers built with the same options, such as the ones a
+// ReloadableMatcher creates on reload, each start with an empty cache, so cached results never
+// outlive the rule set that produced them. Values below 1 disable the cache.
+func WithCache(size int) Option {
+	return func(o *options) {
+		o.cacheSize = size
+	}
+}
+
+// TrackedSet reports whether a path is tracked by version control. Tracked must be safe for
+// concurrent use. *gitindex.Index implements TrackedSet.
+type TrackedSet interface {
+	// Tracked reports whether the normalized, slash-separated path is tracked, or is a directory
+	// containing tracked paths.
+	Tracked(path string) bool
+}
+
+// WithTracked makes the matcher treat paths that set reports as tracked as not ignored, even if a
+// pattern matches them, as git does for files that are already in the index. Together with
+// gitindex.ReadFile this gives the same view of untracked and ignored files as
+// `git status --ignored`, without running git:
+//
+//	index, err := gitindex.ReadFile(".git/index")
+//	...
+//	matcher, err := dotignore.NewPatternMatcherFromFile(".gitignore", dotignore.WithTracked(index))
+//
+// Directories that contain tracked paths are not ignored either, so walks descend into them and
+// classify their untracked contents one by one. A nil set disables the check.
+func WithTracked(set TrackedSet) Option {
+	return func(o *options) {
+		o.tracked = set
+	}
+}
+
+// WithLimits bounds the number and size of patterns a matcher accepts and the size of the paths
+// it matches. Constructors reject patterns that exceed the limits with a *ParseError, while the
+// lenient constructors skip them with a diagnostic; matching a path that exceeds the limits
+// returns ErrPathTooLong or ErrPathTooDeep. Use DefaultLimits for ignore files from untrusted
+// sources.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithUnicodeNormalization makes matching insensitive to the Unicode normalization form, by
+// converting both patterns and paths to NFC before matching. Use it when paths may come from a
+// file system that stores names decomposed, such as macOS, while patterns are typically typed
+// composed: with it, "café/" matches a directory whose name is spelled with a combining accent.
+func WithUnicodeNormalization() Option {
+	return func(o *options) {
+		o.normalize = true
+	}
+}
+
+// WithRoot sets the directory that the patterns are relative to, which lets the matcher accept
+// absolute paths: they are made relative to dir before matching. Paths outside dir, whether
+// absolute or relative with ".." components leading out of it, are rejected with
+// ErrOutsideRoot. A relative dir is resolved against the working directory; symbolic links are
+// not resolved. NewPatternMatcherFromFile and ReloadableMatcher.ReloadFromFile set the root to the
+// directory of the file unless WithRoot is given. An empty dir clears the root.
+func WithRoot(dir string) Option {
+	return func(o *options) {
+		o.root = rootDir(dir)
+		o.rootSet = true
+	}
+}
+
+// rootDir resolves a root directory as WithRoot does.
+func rootDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Clean(dir)
+}
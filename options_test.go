@@ -0,0 +1,230 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codeglyph/go-dotignore/gitindex"
+)
+
+func TestWithTracked(t *testing.T) {
+	// Tracks a.txt, added.txt, build/keep.txt, dir/b.go, dir/sub/c.go, dir/sub/d.go,
+	// "with space.txt" and zzz
+	index, err := gitindex.ReadFile(filepath.Join("gitindex", "testdata", "index-v3"))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	matcher, err := NewPatternMatcher([]string{"*.txt", "build/", "dir/sub/"}, WithTracked(index))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+
+	tests := []struct {
+		file     string
+		isDir    bool
+		expected bool
+	}{
+		{"a.txt", false, false},
+		{"other.txt", false, true},
+		{"build", true, false},
+		{"build/keep.txt", false, false},
+		{"build/out.o", false, true},
+		{"dir/sub", true, false},
+		{"dir/sub/c.go", false, false},
+		{"dir/sub/new.go", false, true},
+		{"dir/notes.txt", false, true},
+	}
+
+	for _, tt := range tests {
+		result, err := matcher.MatchesPath(tt.file, tt.isDir)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if result != tt.expected {
+			t.Errorf("File %q (dir=%v): expected %v, got %v", tt.file, tt.isDir, tt.expected, result)
+		}
+	}
+
+	explanation, err := matcher.Explain("a.txt", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if explanation.Ignored || !explanation.Tracked || explanation.Rule != nil {
+		t.Errorf("Expected a.txt to be tracked and not ignored, got %+v", explanation)
+	}
+
+	// Walks descend into ignored directories that hold tracked files
+	root := createTree(t, "a.txt", "other.txt", "build/keep.txt", "build/out.o", "src/main.go")
+	actions, err := CopyTree(root, t.TempDir(), matcher, CopyOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var copied []string
+	for _, action := range actions {
+		if action.Kind == ActionCopy {
+			copied = append(copied, action.Path)
+		}
+	}
+	expected := []string{"a.txt", "build/keep.txt", "src/main.go"}
+	if !reflect.DeepEqual(copied, expected) {
+		t.Errorf("Expected copied files %v, got %v", expected, copied)
+	}
+
+	untracked, err := NewPatternMatcher([]string{"*.txt"}, WithTracked(nil))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	if ignored, _ := untracked.Matches("a.txt"); !ignored {
+		t.Error("Expected a nil tracked set to be ignored")
+	}
+}
+
+func TestWithUnicodeNormalization(t *testing.T) {
+	const (
+		composed   = "caf\u00e9"
+		decomposed = "cafe\u0301"
+	)
+
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		isDir   bool
+	}{
+		{"composed pattern, decomposed path", composed + "/", decomposed, true},
+		{"decomposed pattern, composed path", decomposed + "/", composed, true},
+		{"file below directory", composed + "/", decomposed + "/menu.txt", false},
+		{"single character wildcard", "caf?", decomposed, false},
+		{"bracket class", "caf[\u00e9e]", decomposed, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plain, err := NewPatternMatcher([]string{test.pattern})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ignored, _ := plain.MatchesPath(test.path, test.isDir); ignored {
+				t.Errorf("Expected %q not to match %q without normalization", test.path, test.pattern)
+			}
+
+			matcher, err := NewPatternMatcher([]string{test.pattern}, WithUnicodeNormalization())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ignored, err := matcher.MatchesPath(test.path, test.isDir); err != nil || !ignored {
+				t.Errorf("Expected %q to match %q, got %v (%v)", test.path, test.pattern, ignored, err)
+			}
+			if explanation, err := matcher.Explain(test.path, test.isDir); err != nil || !explanation.Ignored {
+				t.Errorf("Expected Explain to report %q as ignored, got %+v (%v)", test.path, explanation, err)
+			}
+		})
+	}
+}
+
+func TestNewPatternMatcherFromReaderUTF16(t *testing.T) {
+	// "café/\r\n*.log\r\n" in UTF-16LE with a byte order mark, as written by Windows editors
+	input := "\xff\xfec\x00a\x00f\x00\xe9\x00/\x00\r\x00\n\x00*\x00.\x00l\x00o\x00g\x00\r\x00\n\x00"
+	matcher, err := NewPatternMatcherFromReader(strings.NewReader(input), WithUnicodeNormalization())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, path := range []string{"café/menu.txt", "debug.log"} {
+		if ignored, err := matcher.Matches(path); err != nil || !ignored {
+			t.Errorf("Expected %q to be ignored, got %v (%v)", path, ignored, err)
+		}
+	}
+}
+
+func TestWithRoot(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(filePath, []byte("build/\n*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// The root defaults to the directory of the ignore file
+	matcher, err := NewPatternMatcherFromFile(filePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		expected    bool
+		outsideRoot bool
+	}{
+		{filepath.Join(root, "build", "out.o"), true, false},
+		{filepath.Join(root, "src", "debug.log"), true, false},
+		{filepath.Join(root, "src", "main.go"), false, false},
+		{root, false, false},
+		{"build/out.o", true, false},
+		{"src/../debug.log", true, false},
+		{filepath.Dir(root), false, true},
+		{filepath.Join(filepath.Dir(root), "other", "debug.log"), false, true},
+		{"../debug.log", false, true},
+		{"src/../../debug.log", false, true},
+		{"..", false, true},
+	}
+
+	for _, test := range tests {
+		ignored, err := matcher.Matches(test.path)
+		if test.outsideRoot {
+			if !errors.Is(err, ErrOutsideRoot) {
+				t.Errorf("Expected ErrOutsideRoot for %q, got %v (%v)", test.path, ignored, err)
+			}
+			continue
+		}
+		if err != nil || ignored != test.expected {
+			t.Errorf("Expected %q to be %v, got %v (%v)", test.path, test.expected, ignored, err)
+		}
+	}
+
+	abs := filepath.Join(root, "build", "out.o")
+	if ignored, err := matcher.NewCursor().Matches(abs); err != nil || !ignored {
+		t.Errorf("Expected cursor to ignore %q, got %v (%v)", abs, ignored, err)
+	}
+	if explanation, err := matcher.Explain(abs, false); err != nil || !explanation.Ignored {
+		t.Errorf("Expected Explain to ignore %q, got %+v (%v)", abs, explanation, err)
+	}
+	if ignored, err := matcher.MatchesBytes([]byte(abs), false); err != nil || !ignored {
+		t.Errorf("Expected MatchesBytes to ignore %q, got %v (%v)", abs, ignored, err)
+	}
+	if _, err := matcher.MatchesBytes([]byte("../x"), false); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected ErrOutsideRoot from MatchesBytes, got %v", err)
+	}
+}
+
+func TestWithRootOverride(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(filePath, []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	sub := filepath.Join(root, "sub")
+
+	matcher, err := NewPatternMatcherFromFile(filePath, WithRoot(sub))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := matcher.Matches(filepath.Join(root, "a.log")); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected ErrOutsideRoot outside the explicit root, got %v", err)
+	}
+	if ignored, err := matcher.Matches(filepath.Join(sub, "a.log")); err != nil || !ignored {
+		t.Errorf("Expected path below the explicit root to be ignored, got %v (%v)", ignored, err)
+	}
+
+	// An empty root restores matching paths as given
+	matcher, err = NewPatternMatcherFromFile(filePath, WithRoot(""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ignored, err := matcher.Matches("../a.log"); err != nil || !ignored {
+		t.Errorf("Expected path to be matched as given, got %v (%v)", ignored, err)
+	}
+}
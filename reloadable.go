@@ -0,0 +1,90 @@
+package dotignore
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ReloadableMatcher wraps a PatternMatcher whose rule set can be replaced while other goroutines
+// are matching. Reloads build a new PatternMatcher and swap it in atomically (copy-on-write), so
+// Matches never takes a lock and always sees one complete rule set, either the old or the new one.
+// The zero value is ready to use and serves a matcher with default options and no patterns.
+type ReloadableMatcher struct {
+	current atomic.Pointer[PatternMatcher]
+}
+
+// NewReloadableMatcher returns a ReloadableMatcher that initially serves the given matcher.
+// A nil matcher is replaced by one with no patterns, which ignores nothing.
+func NewReloadableMatcher(matcher *PatternMatcher) *ReloadableMatcher {
+	if matcher == nil {
+		matcher = emptyMatcher()
+	}
+	r := &ReloadableMatcher{}
+	r.current.Store(matcher)
+	return r
+}
+
+// Load returns the PatternMatcher currently in use. The returned matcher is immutable and stays
+// valid after subsequent reloads, which makes it suitable for evaluating a batch of paths against
+// a single consistent rule set.
+func (r *ReloadableMatcher) Load() *PatternMatcher {
+	if matcher := r.current.Load(); matcher != nil {
+		return matcher
+	}
+	return emptyMatcher()
+}
+
+// emptyMatcher returns a matcher with default options and no patterns, which ignores nothing.
+func emptyMatcher() *PatternMatcher {
+	return &PatternMatcher{options: buildOptions(nil)}
+}
+
+// Store atomically replaces the current matcher.
+func (r *ReloadableMatcher) Store(matcher *PatternMatcher) error {
+	if matcher == nil {
+		return errors.New("matcher cannot be nil")
+	}
+	r.current.Store(matcher)
+	return nil
+}
+
+// Reload builds a new matcher from patterns, using the options of the current matcher, and swaps
+// it in. If the patterns fail to parse, the current matcher is kept and the error is returned.
+func (r *ReloadableMatcher) Reload(patterns []string) error {
+	opts := r.Load().options
+	opts.sourceFile = ""
+	matcher, err := newPatternMatcher(patterns, opts)
+	if err != nil {
+		return err
+	}
+	r.current.Store(matcher)
+	return nil
+}
+
+// ReloadFromFile rebuilds the matcher from an ignore file and swaps it in. If the file cannot be
+// read or parsed, the current matcher is kept and the error is returned.
+// The new matcher keeps the options of the current one. Its root is the directory of the file,
+// as with NewPatternMatcherFromFile, unless the current matcher was given one with WithRoot.
+func (r *ReloadableMatcher) ReloadFromFile(filePath string) error {
+	if filePath == "" {
+		return errors.New("file path cannot be empty")
+	}
+
+	opts := withSourceFile(r.Load().options, filePath)
+	patterns, err := readPatternFile(filePath, opts.limits.MaxPatternLength)
+	if err != nil {
+		return err
+	}
+
+	matcher, err := newPatternMatcher(patterns, opts)
+	if err != nil {
+		return err
+	}
+	r.current.Store(matcher)
+	return nil
+}
+
+// Matches reports whether file matches the current rule set. See PatternMatcher.Matches.
+func (r *ReloadableMatcher) Matches(file string) (bool, error) {
+	return r.Load().Matches(file)
+}
@@ -0,0 +1,192 @@
+package dotignore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestReloadableMatcher(t *testing.T) {
+	initial, err := NewPatternMatcher([]string{"*.log"})
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	reloadable := NewReloadableMatcher(initial)
+
+	if matched, _ := reloadable.Matches("app.log"); !matched {
+		t.Error("Expected app.log to be ignored before reload")
+	}
+
+	if err := reloadable.Reload([]string{"*.tmp"}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if matched, _ := reloadable.Matches("app.log"); matched {
+		t.Error("Expected app.log not to be ignored after reload")
+	}
+	if matched, _ := reloadable.Matches("cache.tmp"); !matched {
+		t.Error("Expected cache.tmp to be ignored after reload")
+	}
+
+	// The previously loaded matcher is unaffected by the swap
+	if matched, _ := initial.Matches("app.log"); !matched {
+		t.Error("Expected the original matcher to be unchanged")
+	}
+}
+
+func TestReloadableMatcherKeepsRulesOnError(t *testing.T) {
+	reloadable := NewReloadableMatcher(nil)
+	if err := reloadable.Reload([]string{"build/"}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	before := reloadable.Load()
+	if err := reloadable.Reload([]string{"!"}); err == nil {
+		t.Fatal("Expected error for invalid patterns")
+	}
+	if reloadable.Load() != before {
+		t.Error("Expected the current matcher to be kept after a failed reload")
+	}
+
+	if err := reloadable.Store(nil); err == nil {
+		t.Error("Expected error when storing a nil matcher")
+	}
+}
+
+func TestReloadableMatcherZeroValue(t *testing.T) {
+	var reloadable ReloadableMatcher
+	if matched, err := reloadable.Matches("app.log"); err != nil || matched {
+		t.Errorf("Expected the zero value to ignore nothing, got %v (%v)", matched, err)
+	}
+	if reloadable.Load() == nil {
+		t.Error("Expected Load to return a matcher")
+	}
+
+	if err := reloadable.Reload([]string{"*.log"}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if matched, _ := reloadable.Matches("app.log"); !matched {
+		t.Error("Expected app.log to be ignored after reload")
+	}
+
+	var fromFile ReloadableMatcher
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("dist/\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := fromFile.ReloadFromFile(path); err != nil {
+		t.Fatalf("ReloadFromFile failed: %v", err)
+	}
+	if matched, _ := fromFile.Matches("dist/app.js"); !matched {
+		t.Error("Expected dist/app.js to be ignored after ReloadFromFile")
+	}
+}
+
+func TestReloadableMatcherReloadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(path, []byte("dist/\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	reloadable := NewReloadableMatcher(nil)
+	if matched, _ := reloadable.Matches("dist/app.js"); matched {
+		t.Error("Expected empty matcher to ignore nothing")
+	}
+
+	if err := reloadable.ReloadFromFile(path); err != nil {
+		t.Fatalf("ReloadFromFile failed: %v", err)
+	}
+	if matched, _ := reloadable.Matches("dist/app.js"); !matched {
+		t.Error("Expected dist/app.js to be ignored after reload")
+	}
+
+	if err := reloadable.ReloadFromFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if matched, _ := reloadable.Matches("dist/app.js"); !matched {
+		t.Error("Expected rules to be kept after a failed reload")
+	}
+}
+
+func TestReloadableMatcherReloadFromFileRoot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	if err := os.WriteFile(path, []byte("dist/\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+	other, err := NewPatternMatcherFromFile(filepath.Join(createTree(t, ".gitignore"), ".gitignore"))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	target := filepath.Join(dir, "dist", "app.js")
+
+	// Without WithRoot, the root is the directory of the file that was loaded last
+	for _, initial := range []*PatternMatcher{nil, other} {
+		reloadable := NewReloadableMatcher(initial)
+		if err := reloadable.ReloadFromFile(path); err != nil {
+			t.Fatalf("ReloadFromFile failed: %v", err)
+		}
+		if matched, err := reloadable.Matches(target); err != nil || !matched {
+			t.Errorf("Expected absolute path below the file to be ignored, got %v (%v)", matched, err)
+		}
+	}
+
+	// A root given with WithRoot is kept
+	root := filepath.Join(dir, "sub")
+	initial, err := NewPatternMatcher(nil, WithRoot(root))
+	if err != nil {
+		t.Fatalf("Failed to create matcher: %v", err)
+	}
+	reloadable := NewReloadableMatcher(initial)
+	if err := reloadable.ReloadFromFile(path); err != nil {
+		t.Fatalf("ReloadFromFile failed: %v", err)
+	}
+	if _, err := reloadable.Matches(target); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected ErrOutsideRoot for a path outside the explicit root, got %v", err)
+	}
+	if matched, err := reloadable.Matches(filepath.Join(root, "dist", "app.js")); err != nil || !matched {
+		t.Errorf("Expected dist below the explicit root to be ignored, got %v (%v)", matched, err)
+	}
+}
+
+// TestReloadableMatcherConcurrent is meant to be run with -race.
+func TestReloadableMatcherConcurrent(t *testing.T) {
+	reloadable := NewReloadableMatcher(nil)
+	ruleSets := [][]string{
+		{"*.log", "!important.log"},
+		{"build/", "*.tmp"},
+		{"**/node_modules/", "dist/**"},
+	}
+	files := []string{"app.log", "important.log", "build/app.js", "cache.tmp", "a/node_modules/x.js"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				for _, file := range files {
+					if _, err := reloadable.Matches(file); err != nil {
+						t.Errorf("Unexpected error matching %s: %v", file, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 200; j++ {
+			if err := reloadable.Reload(ruleSets[j%len(ruleSets)]); err != nil {
+				t.Errorf("Reload failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+}
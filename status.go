@@ -0,0 +1,309 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeglyph/go-dotignore/gitindex"
+)
+
+// PathStatus classifies a path in a git working tree.
+type PathStatus int
+
+const (
+	// StatusTracked marks paths that are in the index.
+	StatusTracked PathStatus = iota + 1
+	// StatusUntracked marks paths that are neither tracked nor ignored.
+	StatusUntracked
+	// StatusIgnored marks untracked paths that the ignore rules exclude.
+	StatusIgnored
+)
+
+func (s PathStatus) String() string {
+	switch s {
+	case StatusTracked:
+		return "tracked"
+	case StatusUntracked:
+		return "untracked"
+	case StatusIgnored:
+		return "ignored"
+	default:
+		return fmt.Sprintf("PathStatus(%d)", int(s))
+	}
+}
+
+// StatusEntry is a path reported by Status.
+type StatusEntry struct {
+	// Path is relative to the root of the working tree and uses forward slashes.
+	Path   string
+	Status PathStatus
+	// IsDir is set for directories reported as a whole: ignored directories and nested
+	// repositories.
+	IsDir bool
+}
+
+// String formats the entry like `git status --porcelain`: "?? " for untracked paths, "!! " for
+// ignored paths and two spaces for tracked paths, with a trailing slash for directories.
+func (e StatusEntry) String() string {
+	prefix := "   "
+	switch e.Status {
+	case StatusUntracked:
+		prefix = "?? "
+	case StatusIgnored:
+		prefix = "!! "
+	}
+	if e.IsDir {
+		return prefix + e.Path + "/"
+	}
+	return prefix + e.Path
+}
+
+// StatusOptions controls Status.
+type StatusOptions struct {
+	// CollapseIgnored also reports a directory once, as ignored, when no pattern excludes the
+	// directory itself but every file below it is ignored, as `git status --ignored=traditional`
+	// does. Empty directories are never reported.
+	CollapseIgnored bool
+}
+
+// Status classifies every path in the git working tree at root as tracked, untracked or ignored,
+// reading .git/index, .git/info/exclude and the .gitignore files of every directory directly,
+// without running git. Like git, deeper .gitignore files take precedence over shallower ones,
+// nothing below an excluded directory is re-included, tracked files are never ignored, and lines
+// that cannot be parsed are skipped.
+//
+// Files are reported one by one, except that a directory without tracked files that is excluded
+// by a pattern, or lies below an excluded directory, is reported once as ignored, matching
+// `git status --porcelain --ignored=matching --untracked-files=all`. Nested repositories are
+// reported as single directory entries. Entries are returned in the order of a lexical walk.
+func Status(root string, opts StatusOptions) ([]StatusEntry, error) {
+	if root == "" {
+		return nil, errors.New("root cannot be empty")
+	}
+
+	gitDir, err := findGitDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find git directory of %q: %w", root, err)
+	}
+
+	index, err := gitindex.ReadFile(filepath.Join(gitDir, "index"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// A new repository has no index until the first file is added
+		index, err = &gitindex.Index{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &statusWalker{root: root, index: index, opts: opts}
+	exclude, err := loadIgnoreFile(filepath.Join(gitDir, "info", "exclude"))
+	if err != nil {
+		return nil, err
+	}
+	if exclude != nil {
+		s.exclude = ignoreLevel{matcher: exclude}
+	}
+
+	if _, err := s.walkDir("", false, nil); err != nil {
+		return nil, fmt.Errorf("failed to get status of %q: %w", root, err)
+	}
+	return s.entries, nil
+}
+
+// findGitDir returns the git directory of the working tree at root, following a .git file as
+// created for linked worktrees and submodules.
+func findGitDir(root string) (string, error) {
+	dotGit := filepath.Join(root, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(content), "\n")
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if gitDir == strings.TrimSpace(line) || gitDir == "" {
+		return "", fmt.Errorf("invalid .git file %q", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+	return gitDir, nil
+}
+
+// loadIgnoreFile reads an ignore file, skipping invalid lines. It returns nil if the file does not
+// exist.
+func loadIgnoreFile(filePath string) (*PatternMatcher, error) {
+	matcher, _, err := NewPatternMatcherFromFileLenient(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return matcher, err
+}
+
+// ignoreLevel is the matcher of one ignore file and the directory its patterns are relative to.
+type ignoreLevel struct {
+	// dir is relative to the root, and empty for the root itself
+	dir     string
+	matcher *PatternMatcher
+}
+
+// statusWalker holds the state of a Status run.
+type statusWalker struct {
+	root    string
+	index   *gitindex.Index
+	opts    StatusOptions
+	exclude ignoreLevel
+	entries []StatusEntry
+	info    pathInfo
+}
+
+// walkDir classifies the contents of the directory rel. excluded is set if rel lies in an excluded
+// directory, and levels holds the ignore files that apply to it, outermost first. It reports
+// whether everything reported below rel was ignored, which includes the case that nothing was.
+func (s *statusWalker) walkDir(rel string, excluded bool, levels []ignoreLevel) (bool, error) {
+	dirPath := filepath.Join(s.root, filepath.FromSlash(rel))
+	if !excluded {
+		matcher, err := loadIgnoreFile(filepath.Join(dirPath, ".gitignore"))
+		if err != nil {
+			return false, err
+		}
+		if matcher != nil {
+			levels = append(levels[:len(levels):len(levels)], ignoreLevel{dir: rel, matcher: matcher})
+		}
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return false, err
+	}
+
+	allIgnored := true
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" {
+			continue
+		}
+		child := path.Join(rel, name)
+
+		if !entry.IsDir() {
+			status, err := s.fileStatus(child, excluded, levels)
+			if err != nil {
+				return false, err
+			}
+			allIgnored = allIgnored && status == StatusIgnored
+			s.entries = append(s.entries, StatusEntry{Path: child, Status: status})
+			continue
+		}
+
+		childIgnored, err := s.classifyDir(child, excluded, levels)
+		if err != nil {
+			return false, err
+		}
+		allIgnored = allIgnored && childIgnored
+	}
+
+	return allIgnored, nil
+}
+
+// classifyDir classifies the directory rel and its contents, and reports whether everything
+// reported for it was ignored.
+func (s *statusWalker) classifyDir(rel string, excluded bool, levels []ignoreLevel) (bool, error) {
+	hasTracked := s.index.Tracked(rel)
+	dirExcluded := excluded
+	if !dirExcluded {
+		ignored, err := s.ignored(rel, true, levels)
+		if err != nil {
+			return false, err
+		}
+		dirExcluded = ignored
+	}
+
+	// A nested repository is opaque, unless it is a tracked submodule
+	if _, err := os.Lstat(filepath.Join(s.root, filepath.FromSlash(rel), ".git")); err == nil {
+		entry := StatusEntry{Path: rel, Status: StatusUntracked, IsDir: true}
+		switch {
+		case hasTracked:
+			entry.Status = StatusTracked
+		case dirExcluded:
+			entry.Status = StatusIgnored
+		}
+		s.entries = append(s.entries, entry)
+		return entry.Status == StatusIgnored, nil
+	}
+
+	if dirExcluded && !hasTracked {
+		s.entries = append(s.entries, StatusEntry{Path: rel, Status: StatusIgnored, IsDir: true})
+		return true, nil
+	}
+
+	start := len(s.entries)
+	allIgnored, err := s.walkDir(rel, dirExcluded, levels)
+	if err != nil {
+		return false, err
+	}
+	// Empty directories are not reported, as git does not show them either
+	if allIgnored && s.opts.CollapseIgnored && len(s.entries) > start {
+		s.entries = append(s.entries[:start], StatusEntry{Path: rel, Status: StatusIgnored, IsDir: true})
+	}
+	return allIgnored, nil
+}
+
+// fileStatus classifies the file rel.
+func (s *statusWalker) fileStatus(rel string, excluded bool, levels []ignoreLevel) (PathStatus, error) {
+	if s.index.Tracked(rel) {
+		return StatusTracked, nil
+	}
+	if excluded {
+		return StatusIgnored, nil
+	}
+	ignored, err := s.ignored(rel, false, levels)
+	if err != nil || !ignored {
+		return StatusUntracked, err
+	}
+	return StatusIgnored, nil
+}
+
+// ignored matches rel against the ignore files, from the deepest .gitignore to
+// .git/info/exclude, stopping at the first file with a matching pattern. Like MatchesBytes, it
+// matches names byte by byte.
+func (s *statusWalker) ignored(rel string, isDir bool, levels []ignoreLevel) (bool, error) {
+	for i := len(levels) - 1; i >= -1; i-- {
+		level := s.exclude
+		if i >= 0 {
+			level = levels[i]
+		}
+		if level.matcher == nil {
+			continue
+		}
+
+		sub := rel
+		if level.dir != "" {
+			sub = strings.TrimPrefix(rel, level.dir+"/")
+		}
+		matcher, err := level.matcher.byteMatcher()
+		if err != nil {
+			return false, err
+		}
+		s.info.reset(toByteRunes(sub), isDir)
+		ignored, decisive, err := matcher.evaluate(&s.info, nil)
+		if err != nil {
+			return false, err
+		}
+		if decisive >= 0 {
+			return ignored, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,148 @@
+package dotignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// createStatusTree creates a working tree whose index, testdata/status.index, tracks .gitignore,
+// build/keep.txt and src/main.go.
+func createStatusTree(t *testing.T) string {
+	t.Helper()
+	root := createTree(t,
+		".git/info/",
+		"build/keep.txt", "build/out.o", "build/sub/y.o",
+		"logs/a.log", "logs/empty/",
+		"mixed/a.log", "mixed/b.txt",
+		"node_modules/pkg/i.js",
+		"src/main.go", "src/gen/x.gen", "src/gen/keep.gen",
+		"scratch.tmp", "untracked.txt",
+		"vendor/lib/.git/HEAD", "vendor/lib/file.go",
+	)
+
+	index, err := os.ReadFile(filepath.Join("testdata", "status.index"))
+	if err != nil {
+		t.Fatalf("Failed to read index fixture: %v", err)
+	}
+	files := map[string]string{
+		".git/index":         string(index),
+		".git/info/exclude":  "*.tmp\n",
+		".gitignore":         "build/\n*.log\n*.gen\nnode_modules/\n",
+		"src/gen/.gitignore": "!keep.gen\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return root
+}
+
+func statusLines(entries []StatusEntry) []string {
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, entry.String())
+	}
+	return lines
+}
+
+func TestStatus(t *testing.T) {
+	root := createStatusTree(t)
+
+	entries, err := Status(root, StatusOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// As reported by `git status --porcelain --ignored=matching --untracked-files=all`, in walk
+	// order, with tracked files and the exclude file added
+	expected := []string{
+		"   .gitignore",
+		"   build/keep.txt",
+		"!! build/out.o",
+		"!! build/sub/",
+		"!! logs/a.log",
+		"!! mixed/a.log",
+		"?? mixed/b.txt",
+		"!! node_modules/",
+		"!! scratch.tmp",
+		"?? src/gen/.gitignore",
+		"?? src/gen/keep.gen",
+		"!! src/gen/x.gen",
+		"   src/main.go",
+		"?? untracked.txt",
+		"?? vendor/lib/",
+	}
+	if lines := statusLines(entries); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected status\n%v\ngot\n%v", expected, lines)
+	}
+
+	entries, err = Status(root, StatusOptions{CollapseIgnored: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	collapsed := append(append(append([]string(nil), expected[:4]...), "!! logs/"), expected[5:]...)
+	if lines := statusLines(entries); !reflect.DeepEqual(lines, collapsed) {
+		t.Errorf("Expected collapsed status\n%v\ngot\n%v", collapsed, lines)
+	}
+}
+
+func TestStatusGitFile(t *testing.T) {
+	gitDir := filepath.Join(t.TempDir(), "worktree-git")
+	if err := os.Mkdir(gitDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	root := createTree(t, "a.txt", "b.log")
+	if err := os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n[\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// Without an index nothing is tracked, and the invalid pattern is skipped
+	entries, err := Status(root, StatusOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []StatusEntry{
+		{Path: ".gitignore", Status: StatusUntracked},
+		{Path: "a.txt", Status: StatusUntracked},
+		{Path: "b.log", Status: StatusIgnored},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected %v, got %v", expected, entries)
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	if _, err := Status("", StatusOptions{}); err == nil {
+		t.Error("Expected error for empty root")
+	}
+	if _, err := Status(t.TempDir(), StatusOptions{}); err == nil {
+		t.Error("Expected error for a directory without .git")
+	}
+
+	root := createTree(t, "a.txt")
+	if err := os.WriteFile(filepath.Join(root, ".git"), []byte("not a git file\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Status(root, StatusOptions{}); err == nil {
+		t.Error("Expected error for an invalid .git file")
+	}
+
+	if err := os.Remove(filepath.Join(root, ".git")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".git", "index"), []byte("garbage"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Status(root, StatusOptions{}); err == nil {
+		t.Error("Expected error for an invalid index")
+	}
+}
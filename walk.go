@@ -0,0 +1,62 @@
+package dotignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// walkTree calls fn for every file and directory below root, in lexical order, with its path
+// relative to root using forward slashes. The .git directory is skipped, and fn may return
+// fs.SkipDir to skip a directory.
+func walkTree(root string, fn func(rel string, d fs.DirEntry) error) error {
+	if root == "" {
+		return errors.New("root cannot be empty")
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %q: %w", root, err)
+	}
+	return nil
+}
+
+// walkIncluded is walkTree restricted to the paths the matcher does not ignore. Ignored
+// directories are skipped entirely, so, as in git, nothing below them is visited even if a
+// negation pattern would re-include it.
+func walkIncluded(root string, matcher *PatternMatcher, fn func(rel string, d fs.DirEntry) error) error {
+	if matcher == nil {
+		return errors.New("matcher cannot be nil")
+	}
+
+	return walkTree(root, func(rel string, d fs.DirEntry) error {
+		ignored, err := matcher.MatchesPath(rel, d.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
@@ -0,0 +1,279 @@
+package dotignore
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is the interval used by a FileWatcher when none is given.
+const DefaultPollInterval = time.Second
+
+// WatchEvent describes a reload attempt triggered by a change to one of the watched files.
+type WatchEvent struct {
+	// Matcher is the matcher in effect after the event. When Err is set, it is the last good matcher.
+	Matcher *PatternMatcher
+	// Previous is the matcher that was in effect before the change.
+	Previous *PatternMatcher
+	// Added lists the rules present in the new rule set but not in the previous one.
+	Added []string
+	// Removed lists the rules present in the previous rule set but not in the new one.
+	Removed []string
+	// Err is set when the changed files could not be read or parsed.
+	Err error
+}
+
+// FileWatcher polls one or more ignore files and rebuilds the matcher when their contents change.
+// It relies only on file metadata and content hashes, so it works the same way on every platform.
+// When the files are watched together, their patterns are combined in the order given, as if
+// they had been concatenated into a single file.
+type FileWatcher struct {
+	paths    []string
+	interval time.Duration
+	options  options
+	matcher  *ReloadableMatcher
+
+	mu       sync.Mutex
+	states   []fileState
+	patterns []string
+}
+
+// fileState is the last observed state of a watched file.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+	hash    [sha256.Size]byte
+	// lines holds the raw pattern lines read from the file
+	lines []string
+}
+
+// NewFileWatcher loads the given ignore files and returns a FileWatcher for them. The initial load
+// must succeed; later failures keep the last good matcher. A non-positive interval selects
+// DefaultPollInterval. The options apply to every matcher the watcher builds; as with
+// NewPatternMatcherFromFile, the root defaults to the directory of the first file.
+func NewFileWatcher(interval time.Duration, paths []string, opts ...Option) (*FileWatcher, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("at least one file path is required")
+	}
+	for _, path := range paths {
+		if path == "" {
+			return nil, errors.New("file path cannot be empty")
+		}
+	}
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	w := &FileWatcher{
+		paths:    append([]string(nil), paths...),
+		interval: interval,
+		options:  buildOptions(append([]Option{WithRoot(filepath.Dir(paths[0]))}, opts...)),
+		states:   make([]fileState, len(paths)),
+	}
+
+	for i, path := range paths {
+		state, err := readFileState(path, w.options.limits.MaxPatternLength)
+		if err != nil {
+			return nil, err
+		}
+		w.states[i] = state
+	}
+
+	matcher, patterns, err := w.build(w.states)
+	if err != nil {
+		return nil, err
+	}
+	w.matcher = NewReloadableMatcher(matcher)
+	w.patterns = patterns
+	return w, nil
+}
+
+// Load returns the matcher currently in effect.
+func (w *FileWatcher) Load() *PatternMatcher {
+	return w.matcher.Load()
+}
+
+// Matches reports whether file matches the current rule set. See PatternMatcher.Matches.
+func (w *FileWatcher) Matches(file string) (bool, error) {
+	return w.matcher.Matches(file)
+}
+
+// Poll checks the watched files once. It returns false if none of them changed. Otherwise it
+// rebuilds the matcher and returns an event describing the result; if the rebuild fails, the
+// event carries the error and the previous matcher stays in effect.
+func (w *FileWatcher) Poll() (WatchEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := false
+	states := make([]fileState, len(w.paths))
+	var readErr error
+
+	for i, path := range w.paths {
+		info, err := os.Stat(path)
+		if err == nil && w.states[i].exists && info.Size() == w.states[i].size && info.ModTime().Equal(w.states[i].modTime) {
+			states[i] = w.states[i]
+			continue
+		}
+
+		state, err := readFileState(path, w.options.limits.MaxPatternLength)
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		if state.exists != w.states[i].exists || state.hash != w.states[i].hash {
+			changed = true
+		}
+		states[i] = state
+	}
+
+	// Remember metadata even when contents are unchanged, so touched files are not re-hashed
+	w.states = states
+	if !changed {
+		return WatchEvent{}, false
+	}
+
+	previous := w.matcher.Load()
+	if readErr != nil {
+		return WatchEvent{Matcher: previous, Previous: previous, Err: readErr}, true
+	}
+
+	// Files whose metadata did not change contribute the lines read from them last time
+	matcher, patterns, err := w.build(states)
+	if err != nil {
+		return WatchEvent{Matcher: previous, Previous: previous, Err: err}, true
+	}
+
+	added, removed := diffRules(w.patterns, patterns)
+	w.matcher.Store(matcher)
+	w.patterns = patterns
+	return WatchEvent{Matcher: matcher, Previous: previous, Added: added, Removed: removed}, true
+}
+
+// Watch polls the watched files every interval until ctx is done, sending an event on the returned
+// channel for every change. The channel is closed when ctx is done.
+func (w *FileWatcher) Watch(ctx context.Context) <-chan WatchEvent {
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			event, changed := w.Poll()
+			if !changed {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// build combines the pattern lines of every watched file into a single matcher. Each file is
+// checked against the limits of the watcher and parsed on its own, so that errors point at the
+// right file and line, and its patterns are then numbered as if the files had been concatenated.
+func (w *FileWatcher) build(states []fileState) (*PatternMatcher, []string, error) {
+	var all []string
+	var ignorePatterns []ignorePattern
+	for i, state := range states {
+		opts := w.options
+		opts.sourceFile = w.paths[i]
+		patterns, err := compilePatterns(state.lines, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse patterns from file %q: %w", w.paths[i], err)
+		}
+		for _, pattern := range patterns {
+			pattern.line += len(all)
+			ignorePatterns = append(ignorePatterns, pattern)
+		}
+		all = append(all, state.lines...)
+	}
+
+	// Limits such as MaxPatterns also apply to the files together
+	if err := checkLimits(all, w.options); err != nil {
+		return nil, nil, err
+	}
+	return &PatternMatcher{
+		ignorePatterns: ignorePatterns,
+		options:        w.options,
+		cache:          newResultCache(w.options.cacheSize),
+	}, activeRules(all), nil
+}
+
+// readFileState reads the pattern lines of a file while recording its metadata and content hash.
+// A line longer than maxLength is reported as soon as it is seen, without reading the rest of it.
+func readFileState(path string, maxLength int) (fileState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileState{}, fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fileState{}, fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	hash := sha256.New()
+	lines, err := readPatterns(io.TeeReader(file, hash), maxLength)
+	if err != nil {
+		return fileState{}, fmt.Errorf("failed to parse patterns from file %q: %w", path, withFile(err, path))
+	}
+
+	state := fileState{exists: true, size: info.Size(), modTime: info.ModTime(), lines: lines}
+	copy(state.hash[:], hash.Sum(nil))
+	return state, nil
+}
+
+// activeRules returns the pattern lines that take part in matching, skipping blanks and comments.
+func activeRules(lines []string) []string {
+	var rules []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	return rules
+}
+
+// diffRules compares two rule lists as multisets and returns the rules only present in newRules
+// and the rules only present in oldRules, each in their original order.
+func diffRules(oldRules, newRules []string) (added, removed []string) {
+	counts := make(map[string]int, len(oldRules))
+	for _, rule := range oldRules {
+		counts[rule]++
+	}
+	for _, rule := range newRules {
+		if counts[rule] > 0 {
+			counts[rule]--
+			continue
+		}
+		added = append(added, rule)
+	}
+	for _, rule := range oldRules {
+		if counts[rule] > 0 {
+			counts[rule]--
+			removed = append(removed, rule)
+		}
+	}
+	return added, removed
+}
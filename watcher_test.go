@@ -0,0 +1,274 @@
+package dotignore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeIgnoreFile writes content to path and bumps its modification time so that the change is
+// visible to the watcher even on filesystems with coarse timestamps.
+func writeIgnoreFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+}
+
+func TestFileWatcherPoll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, path, "*.log\nbuild/\n", base)
+
+	watcher, err := NewFileWatcher(time.Millisecond, []string{path})
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+
+	if _, changed := watcher.Poll(); changed {
+		t.Error("Expected no change before the file is modified")
+	}
+
+	writeIgnoreFile(t, path, "*.log\ndist/\n", base.Add(time.Second))
+	event, changed := watcher.Poll()
+	if !changed {
+		t.Fatal("Expected a change after the file is modified")
+	}
+	if event.Err != nil {
+		t.Fatalf("Unexpected reload error: %v", event.Err)
+	}
+	if !reflect.DeepEqual(event.Added, []string{"dist/"}) {
+		t.Errorf("Expected added rules [dist/], got %v", event.Added)
+	}
+	if !reflect.DeepEqual(event.Removed, []string{"build/"}) {
+		t.Errorf("Expected removed rules [build/], got %v", event.Removed)
+	}
+	if event.Matcher != watcher.Load() {
+		t.Error("Expected the event matcher to be the current matcher")
+	}
+	if matched, _ := watcher.Matches("dist/app.js"); !matched {
+		t.Error("Expected dist/app.js to be ignored after reload")
+	}
+	if matched, _ := event.Previous.Matches("dist/app.js"); matched {
+		t.Error("Expected the previous matcher to keep the old rules")
+	}
+
+	// Touching the file without changing its contents does not produce an event
+	writeIgnoreFile(t, path, "*.log\ndist/\n", base.Add(2*time.Second))
+	if _, changed := watcher.Poll(); changed {
+		t.Error("Expected no change when only the modification time changes")
+	}
+}
+
+func TestFileWatcherKeepsLastGoodMatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, path, "*.log\n", base)
+
+	watcher, err := NewFileWatcher(time.Millisecond, []string{path})
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+	good := watcher.Load()
+
+	writeIgnoreFile(t, path, "*.log\n!\n", base.Add(time.Second))
+	event, changed := watcher.Poll()
+	if !changed {
+		t.Fatal("Expected a change after the file is modified")
+	}
+	if event.Err == nil {
+		t.Fatal("Expected a parse error")
+	}
+	if watcher.Load() != good || event.Matcher != good {
+		t.Error("Expected the last good matcher to be kept")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove ignore file: %v", err)
+	}
+	if event, changed := watcher.Poll(); !changed || event.Err == nil {
+		t.Error("Expected an error event when the file is removed")
+	}
+	if _, changed := watcher.Poll(); changed {
+		t.Error("Expected a missing file to be reported only once")
+	}
+
+	// Diffs are computed against the last good rule set
+	writeIgnoreFile(t, path, "*.tmp\n", base.Add(2*time.Second))
+	event, changed = watcher.Poll()
+	if !changed || event.Err != nil {
+		t.Fatalf("Expected a successful reload, got changed=%v err=%v", changed, event.Err)
+	}
+	if !reflect.DeepEqual(event.Added, []string{"*.tmp"}) || !reflect.DeepEqual(event.Removed, []string{"*.log"}) {
+		t.Errorf("Unexpected diff: added %v, removed %v", event.Added, event.Removed)
+	}
+}
+
+func TestFileWatcherMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, ".gitignore")
+	second := filepath.Join(dir, ".git", "info", "exclude")
+	if err := os.MkdirAll(filepath.Dir(second), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, first, "*.log\n", base)
+	writeIgnoreFile(t, second, "!keep.log\n", base)
+
+	watcher, err := NewFileWatcher(time.Millisecond, []string{first, second})
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+	if matched, _ := watcher.Matches("keep.log"); matched {
+		t.Error("Expected later files to override earlier ones")
+	}
+	// Rules are numbered as if the files had been concatenated
+	explanation, err := watcher.Load().Explain("keep.log", false)
+	if err != nil || explanation.Rule == nil || explanation.Rule.Line != 2 {
+		t.Errorf("Expected keep.log to be decided by line 2, got %+v (%v)", explanation.Rule, err)
+	}
+
+	writeIgnoreFile(t, second, "\n", base.Add(time.Second))
+	event, changed := watcher.Poll()
+	if !changed || event.Err != nil {
+		t.Fatalf("Expected a successful reload, got changed=%v err=%v", changed, event.Err)
+	}
+	if matched, _ := watcher.Matches("keep.log"); !matched {
+		t.Error("Expected keep.log to be ignored once the negation is removed")
+	}
+}
+
+func TestFileWatcherWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, path, "*.log\n", base)
+
+	watcher, err := NewFileWatcher(5*time.Millisecond, []string{path})
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := watcher.Watch(ctx)
+
+	writeIgnoreFile(t, path, "*.tmp\n", base.Add(time.Second))
+	select {
+	case event := <-events:
+		if event.Err != nil {
+			t.Fatalf("Unexpected reload error: %v", event.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a watch event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestNewFileWatcherErrors(t *testing.T) {
+	if _, err := NewFileWatcher(time.Second, nil); err == nil {
+		t.Error("Expected error when no paths are given")
+	}
+	if _, err := NewFileWatcher(time.Second, []string{""}); err == nil {
+		t.Error("Expected error for empty path")
+	}
+	if _, err := NewFileWatcher(time.Second, []string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestFileWatcherOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, path, "café/\n", base)
+
+	watcher, err := NewFileWatcher(time.Millisecond, []string{path}, WithUnicodeNormalization())
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+	reference, err := NewPatternMatcherFromFile(path, WithUnicodeNormalization())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Paths are decomposed, as macOS returns them
+	check := func() {
+		t.Helper()
+		for _, file := range []string{"café/menu.txt", filepath.Join(dir, "café", "menu.txt"), filepath.Join(dir, "other.txt")} {
+			expected, _ := reference.Matches(file)
+			if !expected && !strings.HasSuffix(file, "other.txt") {
+				t.Errorf("Expected the file matcher to ignore %q", file)
+			}
+			if matched, err := watcher.Matches(file); err != nil || matched != expected {
+				t.Errorf("Expected watcher to match %q like the file matcher (%v), got %v (%v)", file, expected, matched, err)
+			}
+		}
+		if _, err := watcher.Matches(filepath.Join(filepath.Dir(dir), "x")); !errors.Is(err, ErrOutsideRoot) {
+			t.Errorf("Expected ErrOutsideRoot, got %v", err)
+		}
+	}
+	check()
+
+	// Reloaded matchers keep the options
+	writeIgnoreFile(t, path, "café/\n*.tmp\n", base.Add(time.Second))
+	if event, changed := watcher.Poll(); !changed || event.Err != nil {
+		t.Fatalf("Expected a successful reload, got %v (%v)", changed, event.Err)
+	}
+	check()
+}
+
+func TestFileWatcherLimits(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, ".gitignore")
+	second := filepath.Join(dir, "extra.ignore")
+	base := time.Now().Add(-time.Hour)
+	writeIgnoreFile(t, first, "*.log\n", base)
+	writeIgnoreFile(t, second, "build/\n", base)
+
+	limits := WithLimits(Limits{MaxPatternLength: 10, MaxDoubleStars: 1})
+	watcher, err := NewFileWatcher(time.Millisecond, []string{first, second}, limits)
+	if err != nil {
+		t.Fatalf("NewFileWatcher failed: %v", err)
+	}
+
+	tests := []struct {
+		content  string
+		expected error
+		line     int
+		column   int
+	}{
+		// A long line is reported where reading it stopped, at the limit
+		{"build/\n" + strings.Repeat("x", 1000) + "\n", ErrPatternTooLong, 2, 11},
+		{"**/a/**/b\n", ErrTooManyDoubleStars, 1, 1},
+	}
+
+	for i, test := range tests {
+		writeIgnoreFile(t, second, test.content, base.Add(time.Duration(i+1)*time.Second))
+		event, changed := watcher.Poll()
+		if !changed {
+			t.Fatalf("Expected a change for %q", test.content)
+		}
+		var parseErr *ParseError
+		if !errors.Is(event.Err, test.expected) || !errors.As(event.Err, &parseErr) {
+			t.Fatalf("Expected a ParseError for %v, got %v", test.expected, event.Err)
+		}
+		if parseErr.File != second || parseErr.Line != test.line || parseErr.Column != test.column {
+			t.Errorf("Expected error at %s:%d:%d, got %s:%d:%d", second, test.line, test.column, parseErr.File, parseErr.Line, parseErr.Column)
+		}
+	}
+
+	// The last good matcher stays in use
+	if matched, err := watcher.Matches("debug.log"); err != nil || !matched {
+		t.Errorf("Expected debug.log to stay ignored, got %v (%v)", matched, err)
+	}
+}